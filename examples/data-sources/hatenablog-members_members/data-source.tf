@@ -0,0 +1,14 @@
+data "hatenablog-members_members" "all" {}
+
+# Import all the existing members of the blog at once (Terraform 1.7 or later)
+import {
+  for_each = { for m in data.hatenablog-members_members.all.members : m.username => m }
+  to       = hatenablog-members_member.members[each.key]
+  id       = each.value.import_id
+}
+
+resource "hatenablog-members_member" "members" {
+  for_each = { for m in data.hatenablog-members_members.all.members : m.username => m }
+  username = each.value.username
+  role     = each.value.role
+}
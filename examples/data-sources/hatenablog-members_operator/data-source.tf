@@ -0,0 +1,8 @@
+data "hatenablog-members_operator" "current" {}
+
+# Skip the member when the operator cannot manage members of the blog
+resource "hatenablog-members_member" "admin" {
+  count    = data.hatenablog-members_operator.current.can_manage_members ? 1 : 0
+  username = "hatenablog-tf-test2"
+  role     = "admin"
+}
@@ -0,0 +1,11 @@
+data "hatenablog-members_roles" "all" {}
+
+data "hatenablog-members_members" "all" {}
+
+# The members who can publish posts, i.e. at least editor
+output "publishers" {
+  value = [
+    for m in data.hatenablog-members_members.all.members : m.username
+    if data.hatenablog-members_roles.all.ranks[m.role] >= data.hatenablog-members_roles.all.ranks["editor"]
+  ]
+}
@@ -0,0 +1,7 @@
+locals {
+  blog = provider::hatenablog-members::parse_blog_url("https://staff.hatenablog.com/")
+}
+
+output "blog_host" {
+  value = local.blog.blog_host # => "staff.hatenablog.com"
+}
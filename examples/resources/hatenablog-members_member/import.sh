@@ -0,0 +1,19 @@
+# A member can be imported by its Hatena ID
+# The role is imported as its value in the API, e.g. 'admin'. If 'role' is written as a Japanese label or an alias,
+# the next plan shows it as an update, which only rewrites the state without calling the API.
+terraform import hatenablog-members_member.example hatenablog-tf-test2
+
+# or with the blog to make sure that the provider is configured for it
+terraform import hatenablog-members_member.example tf-test.hatenablog.com:hatenablog-tf-test2
+terraform import hatenablog-members_member.example hatenablog-tf-test/tf-test.hatenablog.com/hatenablog-tf-test2
+
+# With Terraform 1.12 or later, an import block can also identify the member by its resource identity:
+#
+# import {
+#   to = hatenablog-members_member.example
+#   identity = {
+#     owner     = "hatenablog-tf-test"
+#     blog_host = "tf-test.hatenablog.com"
+#     username  = "hatenablog-tf-test2"
+#   }
+# }
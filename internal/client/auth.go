@@ -0,0 +1,66 @@
+package client
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Authenticator adds the credentials of the operator to requests to the Hatena Blog API.
+type Authenticator interface {
+	// Transport returns an http.RoundTripper which sends requests through base with the credentials.
+	Transport(base http.RoundTripper) http.RoundTripper
+}
+
+// WSSEAuthenticator authenticates requests with the X-WSSE header
+// using the Hatena ID and the API key of the operator.
+//
+// When the Date header of a response shows that the local clock is off by more than a minute,
+// the Created timestamps of later requests are corrected by the difference,
+// and a request rejected with 401 Unauthorized is retried once.
+//
+// When a request is rejected with 401 Unauthorized, the FallbackAPIKeys are tried in order,
+// and the first one accepted is used for later requests.
+// A WSSEAuthenticator must not be copied after first use.
+type WSSEAuthenticator struct {
+	Username        string
+	APIKey          string
+	FallbackAPIKeys []string
+
+	// offset は Created に加えるサーバーとの時計のずれ
+	offset atomic.Int64
+	// keyIndex は使用しているAPIキーの番号。0 は APIKey、i は FallbackAPIKeys[i-1]
+	keyIndex atomic.Int32
+
+	// テストで値を固定するために差し替える
+	nowFunc   func() time.Time
+	nonceFunc func() ([]byte, error)
+}
+
+// ensure that WSSEAuthenticator satisfies interfaces
+var _ Authenticator = &WSSEAuthenticator{}
+
+func (a *WSSEAuthenticator) Transport(base http.RoundTripper) http.RoundTripper {
+	return &wsseTransport{authenticator: a, base: base}
+}
+
+// ClockOffset returns the correction added to the Created timestamps,
+// i.e. how far the server's clock was found to be ahead of the local clock.
+// It is zero unless the clocks differ by more than a minute.
+func (a *WSSEAuthenticator) ClockOffset() time.Duration {
+	return time.Duration(a.offset.Load())
+}
+
+// APIKeyIndex returns which API key is used: 0 for APIKey and i for FallbackAPIKeys[i-1].
+// It is not 0 if APIKey has been rejected and one of the FallbackAPIKeys has been accepted.
+func (a *WSSEAuthenticator) APIKeyIndex() int {
+	return int(a.keyIndex.Load())
+}
+
+// apiKey は番号に対応するAPIキーを返す
+func (a *WSSEAuthenticator) apiKey(index int) string {
+	if index == 0 {
+		return a.APIKey
+	}
+	return a.FallbackAPIKeys[index-1]
+}
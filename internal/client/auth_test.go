@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var wssePattern = regexp.MustCompile(`^UsernameToken Username="([^"]*)", PasswordDigest="([^"]*)", Nonce="([^"]*)", Created="([^"]*)"$`)
+
+// verifyWSSE はサーバー側で X-WSSE ヘッダのダイジェストを検証する
+func verifyWSSE(r *http.Request, username, apikey string) error {
+	m := wssePattern.FindStringSubmatch(r.Header.Get("X-WSSE"))
+	if m == nil {
+		return fmt.Errorf("invalid X-WSSE header: %q", r.Header.Get("X-WSSE"))
+	}
+	if m[1] != username {
+		return fmt.Errorf("unexpected username: %s", m[1])
+	}
+	nonce, err := base64.StdEncoding.DecodeString(m[3])
+	if err != nil {
+		return err
+	}
+	digest := sha1.Sum([]byte(string(nonce) + m[4] + apikey))
+	if m[2] != base64.StdEncoding.EncodeToString(digest[:]) {
+		return fmt.Errorf("digest mismatch: %s", m[2])
+	}
+	return nil
+}
+
+func TestWSSEAuthenticator_Transport(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc("/owner/blog.example.com/api/members", func(w http.ResponseWriter, r *http.Request) {
+		if err := verifyWSSE(r, "username", "apikey"); err != nil {
+			t.Errorf("invalid credentials: %s", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("unexpected Authorization header")
+		}
+		fmt.Fprint(w, `{"members":[]}`)
+	})
+
+	if _, ok := client.Authenticator().(*WSSEAuthenticator); !ok {
+		t.Errorf("unexpected authenticator: %T", client.Authenticator())
+	}
+	if _, err := client.ListMembers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestWSSEAuthenticator_FallbackAPIKeys(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	var keys []string
+	mux.HandleFunc("/owner/blog.example.com/api/members", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		for _, key := range []string{"stale", "old", "current"} {
+			if verifyWSSE(r, "username", key) == nil {
+				keys = append(keys, key)
+				if key != "current" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+			}
+		}
+		if r.Method == "POST" && string(body) != `{"username":"member","role":"editor"}` {
+			t.Errorf("unexpected body: %s", body)
+		}
+		fmt.Fprint(w, `{"members":[],"username":"member","role":"editor"}`)
+	})
+
+	var buf bytes.Buffer
+	a := &WSSEAuthenticator{Username: "username", APIKey: "stale", FallbackAPIKeys: []string{"old", "current", "unused"}}
+	client := NewClient("test", "username", "", "owner", "blog.example.com",
+		WithAuthenticator(a), WithBaseURL(mustParseURL(t, server.URL)), WithCache(false), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	// 予備のAPIキーを順に試し、本文も送り直す
+	if _, err := client.AddMember(context.Background(), "member", "editor"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Join(keys, ",") != "stale,old,current" {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+	if client.APIKeyIndex() != 2 {
+		t.Errorf("unexpected API key index: %d", client.APIKeyIndex())
+	}
+	if !strings.Contains(buf.String(), "level=WARN") || !strings.Contains(buf.String(), "stale_index=0 index=2") {
+		t.Errorf("the stale API key should be warned: %s", buf.String())
+	}
+
+	// 以降は成功したAPIキーを使う
+	keys = nil
+	if _, err := client.ListMembers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Join(keys, ",") != "current" {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+}
+
+func TestWSSEAuthenticator_FallbackAPIKeys_allRejected(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	var requests int
+	mux.HandleFunc("/owner/blog.example.com/api/members", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	a := &WSSEAuthenticator{Username: "username", APIKey: "stale", FallbackAPIKeys: []string{"old"}}
+	client := NewClient("test", "username", "", "owner", "blog.example.com", WithAuthenticator(a), WithBaseURL(mustParseURL(t, server.URL)))
+
+	if _, err := client.ListMembers(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("unexpected number of requests: %d", requests)
+	}
+	if client.APIKeyIndex() != 0 {
+		t.Errorf("unexpected API key index: %d", client.APIKeyIndex())
+	}
+}
+
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
@@ -4,13 +4,18 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
 	"sync"
+	"time"
 )
 
 type BlogMember struct {
@@ -24,62 +29,287 @@ type membersCache struct {
 	Members []*BlogMember
 }
 
+// initialMembers はこのクライアントが変更を加える前のメンバー一覧
+type initialMembers struct {
+	sync.Mutex
+	Members []*BlogMember
+	// Modified はこのクライアントがメンバーを変更したかどうか
+	Modified bool
+}
+
 type Client struct {
-	client *http.Client
+	client    *http.Client
+	transport *transport
+	// httpTransport は X-WSSE ヘッダを付与したリクエストを実際に送信するトランスポート
+	httpTransport *http.Transport
+	authenticator Authenticator
 
 	username       string
 	owner          string
 	blogHost       string
-	hatenablogHost string
-	insecure       bool
+	endpoint       url.URL
+	cache          bool
 	membersCache   membersCache
+	initialMembers initialMembers
 }
 
-func NewClient(version, username, apikey, owner, blogHost string) *Client {
-	return &Client{
-		client: &http.Client{
-			Transport: newTransport(username, apikey, version),
-		},
-		username:       username,
-		owner:          owner,
-		blogHost:       blogHost,
-		hatenablogHost: "blog.hatena.ne.jp",
-		insecure:       false,
+// DefaultEndpoint is the URL of the Hatena Blog API used unless WithBaseURL is given or SetEndpoint is called.
+const DefaultEndpoint = "https://blog.hatena.ne.jp"
+
+// NewClient creates a client of the Hatena Blog API for the blog blogHost owned by owner,
+// authenticated as username with apikey unless WithAuthenticator is given.
+// version is used in the User-Agent header.
+// Only these values, which every client needs, are positional; everything else is configured with an Option.
+func NewClient(version, username, apikey, owner, blogHost string, opts ...Option) *Client {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// X-WSSE ヘッダを付与したリクエストを送信するトランスポートを決める
+	// 独自のトランスポートが渡されていないときだけ、TLSやプロキシの設定のために http.Transport を複製して使う
+	var base http.RoundTripper
+	var httpTransport *http.Transport
+	switch {
+	case o.transport != nil:
+		base = o.transport
+	case o.httpClient != nil && o.httpClient.Transport != nil:
+		base = o.httpClient.Transport
+	default:
+		httpTransport = http.DefaultTransport.(*http.Transport).Clone()
+		base = httpTransport
+	}
+	authenticator := o.authenticator
+	if authenticator == nil {
+		authenticator = &WSSEAuthenticator{Username: username, APIKey: apikey}
+	}
+	transport := newTransport(authenticator, base, version, o.userAgentSuffix, o.logger, o.harFile)
+
+	httpClient := &http.Client{}
+	if o.httpClient != nil {
+		*httpClient = *o.httpClient
+	}
+	httpClient.Transport = transport
+	if httpClient.CheckRedirect == nil {
+		// APIはリダイレクトしないので、リダイレクトされたときはログインページなどへの誘導としてエラーにする
+		httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	baseURL := o.baseURL
+	if baseURL == nil {
+		baseURL, _ = url.Parse(DefaultEndpoint)
+	}
+
+	c := &Client{
+		client:        httpClient,
+		transport:     transport,
+		httpTransport: httpTransport,
+		authenticator: authenticator,
+		username:      username,
+		owner:         owner,
+		blogHost:      blogHost,
+		cache:         o.cache,
 		membersCache: membersCache{
 			Members: nil,
 		},
 	}
+	c.setBaseURL(baseURL)
+	return c
+}
+
+// Username returns the Hatena ID of the operator.
+func (c *Client) Username() string {
+	return c.username
+}
+
+// Owner returns the Hatena ID of the owner of the blog.
+func (c *Client) Owner() string {
+	return c.owner
+}
+
+// BlogHost returns the host of the blog.
+func (c *Client) BlogHost() string {
+	return c.blogHost
+}
+
+// Authenticator returns the Authenticator which adds the credentials to requests.
+func (c *Client) Authenticator() Authenticator {
+	return c.authenticator
 }
 
+// Endpoint returns the base URL of the API for the blog.
+func (c *Client) Endpoint() string {
+	return c.buildURL().String()
+}
+
+// ClockSkew returns how far the server's clock is ahead of the local clock,
+// measured from the Date header of the last response.
+// It returns false if no response with a Date header has been received yet.
+func (c *Client) ClockSkew() (time.Duration, bool) {
+	if !c.transport.hasClockSkew.Load() {
+		return 0, false
+	}
+	return time.Duration(c.transport.clockSkew.Load()), true
+}
+
+// ClockOffset returns the correction the authenticator adds to its timestamps
+// because the local clock differs from the server's clock. See WSSEAuthenticator.
+func (c *Client) ClockOffset() time.Duration {
+	return clockOffset(c.authenticator)
+}
+
+// APIKeyIndex returns which API key the authenticator uses:
+// 0 for the primary one and i for the i-th fallback one. See WSSEAuthenticator.
+func (c *Client) APIKeyIndex() int {
+	return apiKeyIndex(c.authenticator)
+}
+
+// SetEndpoint sets the URL of the Hatena Blog API.
+// The URL consists of a scheme (http or https), a host with an optional port, and an optional path prefix,
+// e.g. "http://localhost:8080/staging".
+func (c *Client) SetEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid endpoint %q: the scheme must be http or https", endpoint)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid endpoint %q: the host is missing", endpoint)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid endpoint %q: the endpoint must not have user info, a query or a fragment", endpoint)
+	}
+
+	c.setBaseURL(u)
+	return nil
+}
+
+// setBaseURL はAPIのURLを設定する。WithBaseURL と SetEndpoint、非推奨のメソッドはすべてここを通す
+func (c *Client) setBaseURL(u *url.URL) {
+	c.endpoint = normalizeBaseURL(u)
+}
+
+// SetHatenablogHost sets the host of the endpoint, keeping its scheme and path.
+//
+// Deprecated: Use WithBaseURL or SetEndpoint instead. The provider passes its deprecated attributes
+// to SetEndpoint, so this method is no longer called and will be removed.
 func (c *Client) SetHatenablogHost(host string) {
-	c.hatenablogHost = host
+	u := c.endpoint
+	u.Host = host
+	c.setBaseURL(&u)
 }
 
+// SetInsecure makes the client use http instead of https for the endpoint, keeping its host and path.
+//
+// Deprecated: Use WithBaseURL or SetEndpoint instead. The provider passes its deprecated attributes
+// to SetEndpoint, so this method is no longer called and will be removed.
 func (c *Client) SetInsecure(insecure bool) {
-	c.insecure = insecure
+	u := c.endpoint
+	u.Scheme = "https"
+	if insecure {
+		u.Scheme = "http"
+	}
+	c.setBaseURL(&u)
 }
 
-func (c *Client) AddMember(username, role string) (*BlogMember, error) {
+// errCustomTransport は独自のトランスポートを使うクライアントにTLSやプロキシを設定しようとしたときのエラー
+var errCustomTransport = errors.New("cannot configure a custom transport; configure it before passing it to the client")
+
+// SetCACerts makes the client trust the certificates in the PEM encoded data
+// in addition to the system's certificate pool, e.g. the CA of an intercepting proxy.
+// It can be called more than once to add certificates.
+// It fails if the client was created with WithHTTPClient or WithTransport with their own transport.
+func (c *Client) SetCACerts(pemCerts []byte) error {
+	if c.httpTransport == nil {
+		return errCustomTransport
+	}
+	tlsConfig := c.httpTransport.TLSClientConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+
+	pool := tlsConfig.RootCAs
+	if pool == nil {
+		var err error
+		pool, err = x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+	} else {
+		pool = pool.Clone()
+	}
+	if !pool.AppendCertsFromPEM(pemCerts) {
+		return fmt.Errorf("no PEM encoded certificates found")
+	}
+
+	tlsConfig.RootCAs = pool
+	c.httpTransport.TLSClientConfig = tlsConfig
+	return nil
+}
+
+// SetProxy makes the client send requests via the proxy.
+// By default, the proxy is taken from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+// It fails if the client was created with WithHTTPClient or WithTransport with their own transport.
+func (c *Client) SetProxy(proxyURL string) error {
+	if c.httpTransport == nil {
+		return errCustomTransport
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("invalid proxy URL %q: the scheme must be http, https, socks5 or socks5h", proxyURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q: the host is missing", proxyURL)
+	}
+
+	c.httpTransport.Proxy = http.ProxyURL(u)
+	return nil
+}
+
+// SetRequestTimeout sets the time limit for each request to the API.
+// Zero means no timeout.
+func (c *Client) SetRequestTimeout(timeout time.Duration) {
+	c.client.Timeout = timeout
+}
+
+// RequestTimeout returns the time limit for each request to the API.
+func (c *Client) RequestTimeout() time.Duration {
+	return c.client.Timeout
+}
+
+func (c *Client) AddMember(ctx context.Context, username, role string) (*BlogMember, error) {
 	data := BlogMember{
 		Username: username,
 		Role:     role,
 	}
 	body, _ := json.Marshal(data)
 
-	req, err := http.NewRequest("POST", c.buildURL("members").String(), bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.buildURL("members").String(), bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
+	c.markModified()
+
 	res, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
-	if !(200 <= res.StatusCode && res.StatusCode < 300) {
-		respBody, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", res.StatusCode, respBody)
+	if err := checkResponse(req, res); err != nil {
+		return nil, err
 	}
 
 	buf, err := io.ReadAll(res.Body)
@@ -100,25 +330,30 @@ func (c *Client) AddMember(username, role string) (*BlogMember, error) {
 }
 
 // ListMembers lists members of the blog.
-func (c *Client) ListMembers() ([]*BlogMember, error) {
+func (c *Client) ListMembers(ctx context.Context) ([]*BlogMember, error) {
 	// terraform plan時にresourceの数だけこのメソッドが実行される
 	// キャッシュがあるときはそれを返すことでリクエストの実行数を減らす
+	// キャッシュを無効にしていても、InitialMembers のために取得したメンバー一覧は保存しておく
 	c.membersCache.RLock()
-	if c.membersCache.Members != nil {
+	if c.cache && c.membersCache.Members != nil {
 		members := append([]*BlogMember{}, c.membersCache.Members...)
 		c.membersCache.RUnlock()
 		return members, nil
 	}
 	c.membersCache.RUnlock()
 
-	res, err := c.client.Get(c.buildURL("members").String())
+	req, err := http.NewRequestWithContext(ctx, "GET", c.buildURL("members").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
-	if !(200 <= res.StatusCode && res.StatusCode < 300) {
-		respBody, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", res.StatusCode, respBody)
+	if err := checkResponse(req, res); err != nil {
+		return nil, err
 	}
 
 	buf, err := io.ReadAll(res.Body)
@@ -140,20 +375,21 @@ func (c *Client) ListMembers() ([]*BlogMember, error) {
 	return c.membersCache.Members, nil
 }
 
-func (c *Client) DeleteMember(username string) error {
-	req, err := http.NewRequest("DELETE", c.buildURL("members", username).String(), nil)
+func (c *Client) DeleteMember(ctx context.Context, username string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.buildURL("members", username).String(), nil)
 	if err != nil {
 		return err
 	}
 
+	c.markModified()
+
 	res, err := c.client.Do(req)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
-	if !(200 <= res.StatusCode && res.StatusCode < 300) {
-		respBody, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("unexpected status code: %d, body: %s", res.StatusCode, respBody)
+	if err := checkResponse(req, res); err != nil {
+		return err
 	}
 
 	c.membersCache.Lock()
@@ -163,21 +399,52 @@ func (c *Client) DeleteMember(username string) error {
 	return nil
 }
 
+// InitialMembers returns the members of the blog as they were before this client changed them.
+// The first call fetches the members if they have not been fetched yet,
+// so it must be called before any change is made to detect changes made by others.
+func (c *Client) InitialMembers(ctx context.Context) ([]*BlogMember, error) {
+	c.initialMembers.Lock()
+	defer c.initialMembers.Unlock()
+
+	if c.initialMembers.Members == nil {
+		if c.initialMembers.Modified {
+			return nil, fmt.Errorf("members have already been changed by this client")
+		}
+		members, err := c.ListMembers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.initialMembers.Members = members
+	}
+
+	return append([]*BlogMember{}, c.initialMembers.Members...), nil
+}
+
+// markModified はこのクライアントがメンバーを変更したことを記録する
+// それまでに取得したメンバー一覧があれば InitialMembers のために保存しておく
+func (c *Client) markModified() {
+	c.initialMembers.Lock()
+	defer c.initialMembers.Unlock()
+
+	if c.initialMembers.Members == nil && !c.initialMembers.Modified {
+		c.membersCache.RLock()
+		if c.membersCache.Members != nil {
+			c.initialMembers.Members = append([]*BlogMember{}, c.membersCache.Members...)
+		}
+		c.membersCache.RUnlock()
+	}
+	c.initialMembers.Modified = true
+}
+
 // buildURL ははてなブログのAPIのURLを生成するためのヘルパ関数
 // 生成するURLは次の形式
-// (http|https)://<hatenablogHost>/<owner>/<blogHost>/api/<p...>
+// <endpoint>/<owner>/<blogHost>/api/<p...>
 func (c *Client) buildURL(p ...string) *url.URL {
-	paths := make([]string, 0, len(p)+3)
-	paths = append(paths, c.owner, c.blogHost, "api")
+	paths := make([]string, 0, len(p)+5)
+	paths = append(paths, "/", c.endpoint.Path, c.owner, c.blogHost, "api")
 	paths = append(paths, p...)
 
-	scheme := "https"
-	if c.insecure {
-		scheme = "http"
-	}
-	return &url.URL{
-		Scheme: scheme,
-		Host:   c.hatenablogHost,
-		Path:   path.Join(paths...),
-	}
+	u := c.endpoint
+	u.Path = path.Join(paths...)
+	return &u
 }
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// APIError の種類。errors.Is で判定できる
+var (
+	// ErrUnauthorized はAPIキーなどの認証情報が誤っているときのエラー
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden は操作するユーザーにブログの管理権限がないときのエラー
+	ErrForbidden = errors.New("forbidden")
+	// ErrBlogNotFound は owner と blog_host に対応するブログが見つからないときのエラー
+	ErrBlogNotFound = errors.New("blog not found")
+	// ErrLoginRedirect はリクエストが認証されずにログインページにリダイレクトされたときのエラー
+	ErrLoginRedirect = errors.New("redirected to login page")
+)
+
+// APIError はAPIが成功以外のレスポンスを返したときのエラー
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+	// Location はリダイレクトされたときのリダイレクト先
+	Location string
+}
+
+func (e *APIError) Error() string {
+	if e.Location != "" {
+		return fmt.Sprintf("unexpected status code: %d, location: %s", e.StatusCode, e.Location)
+	}
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrBlogNotFound:
+		// メンバーのURLが404のときはメンバーが存在しないだけなので、メンバー一覧のURLのときのみ
+		return e.StatusCode == http.StatusNotFound && strings.HasSuffix(e.URL, "/api/members")
+	case ErrLoginRedirect:
+		return 300 <= e.StatusCode && e.StatusCode < 400 && strings.Contains(e.Location, "login")
+	}
+	return false
+}
+
+// checkResponse はレスポンスのステータスコードが成功でなければ APIError を返す
+// WithTransport で渡されたトランスポートは res.Request を設定しないことがあるので、送信したリクエストを受け取る
+func checkResponse(req *http.Request, res *http.Response) error {
+	if 200 <= res.StatusCode && res.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(res.Body)
+	return &APIError{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Body:       string(body),
+		Location:   res.Header.Get("Location"),
+	}
+}
@@ -0,0 +1,283 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// harFiles は HAR ファイルのパスごとの harFile
+// 同じファイルに書き込むクライアントが複数あってもエントリーを失わないように共有する
+var harFiles sync.Map
+
+// harFile はAPIとのやりとりを HAR 1.2 の形式で追記するファイル
+type harFile struct {
+	sync.Mutex
+	path string
+}
+
+// openHARFile はパスに対応する harFile を返す
+func openHARFile(path string) *harFile {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	f, _ := harFiles.LoadOrStore(path, &harFile{path: path})
+	return f.(*harFile)
+}
+
+// harSuffix は append が書き出す HAR の末尾。HAR はエントリーの一覧で終わるので、ここを置き換えて追記する
+const harSuffix = "\n    ]\n  }\n}"
+
+// append はファイルの HAR にエントリーを追記する
+// 排他はプロセスの中だけなので、複数のプロセスから同じファイルに書き込んではいけない
+func (f *harFile) append(entry harEntry, creator harCreator) error {
+	f.Lock()
+	defer f.Unlock()
+
+	appended, err := f.appendInPlace(entry)
+	if err != nil || appended {
+		return err
+	}
+	return f.rewrite(entry, creator)
+}
+
+// appendInPlace は HAR の末尾をエントリーで置き換えて追記する
+// ファイルを読み直さないので、エントリーが増えても追記にかかる時間は変わらない
+// ファイルがないときや、エントリーがないなど append が書き出した形式で終わっていないときは false を返す
+func (f *harFile) appendInPlace(entry harEntry) (bool, error) {
+	file, err := os.OpenFile(f.path, os.O_RDWR, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	// 最後のエントリーの閉じ括弧の直後から書き込む
+	offset := info.Size() - int64(len(harSuffix))
+	if offset < 1 {
+		return false, nil
+	}
+	tail := make([]byte, len(harSuffix)+1)
+	if _, err := file.ReadAt(tail, offset-1); err != nil {
+		return false, err
+	}
+	if string(tail) != "}"+harSuffix {
+		return false, nil
+	}
+
+	buf, err := json.MarshalIndent(entry, "      ", "  ")
+	if err != nil {
+		return false, err
+	}
+	buf = append(append([]byte(",\n      "), buf...), harSuffix...)
+	if _, err := file.WriteAt(buf, offset); err != nil {
+		return false, err
+	}
+	return true, file.Close()
+}
+
+// rewrite はファイルの HAR を読み込んでエントリーを追記したものを、一時ファイルに書き出してから置き換える
+// ファイルがないときは新しく作る
+func (f *harFile) rewrite(entry harEntry, creator harCreator) error {
+	har := harDocument{Log: harLog{Version: "1.2", Creator: creator, Entries: []harEntry{}}}
+	buf, err := os.ReadFile(f.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	case len(buf) > 0:
+		if err := json.Unmarshal(buf, &har); err != nil {
+			return fmt.Errorf("%s is not a HAR file: %w", f.path, err)
+		}
+	}
+	har.Log.Entries = append(har.Log.Entries, entry)
+
+	buf, err = json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), "."+filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// HAR 1.2 の形式 (http://www.softwareishard.com/blog/har-12-spec/) のうち、クライアントが書き出す部分
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	// Error はレスポンスを受け取れなかったときのエラー。HAR の独自フィールドは "_" で始める
+	Error string `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// newHAREntry はリクエストとレスポンスから認証情報を伏せた HAR のエントリーを作る
+// sent は実際に送信した署名済みのリクエスト、res はレスポンスを受け取れなかったときは nil
+func newHAREntry(req, sent *http.Request, reqBody []byte, res *http.Response, resBody []byte, start time.Time, duration time.Duration, roundTripErr error) harEntry {
+	milliseconds := float64(duration.Microseconds()) / 1000
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.Redacted(),
+			HTTPVersion: req.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(sent.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: harTimings{Send: 0, Wait: milliseconds, Receive: 0},
+	}
+	if entry.Request.HTTPVersion == "" {
+		entry.Request.HTTPVersion = "HTTP/1.1"
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(entry.Request.QueryString, func(i, j int) bool {
+		return entry.Request.QueryString[i].Name < entry.Request.QueryString[j].Name
+	})
+	if reqBody != nil {
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: string(reqBody)}
+	}
+
+	if roundTripErr != nil {
+		entry.Error = roundTripErr.Error()
+		return entry
+	}
+
+	entry.Response.Status = res.StatusCode
+	entry.Response.StatusText = strings.TrimSpace(strings.TrimPrefix(res.Status, fmt.Sprint(res.StatusCode)))
+	entry.Response.HTTPVersion = res.Proto
+	if entry.Response.HTTPVersion == "" {
+		entry.Response.HTTPVersion = "HTTP/1.1"
+	}
+	entry.Response.Headers = harHeaders(res.Header)
+	entry.Response.RedirectURL = res.Header.Get("Location")
+	entry.Response.BodySize = len(resBody)
+	entry.Response.Content = harContent{Size: len(resBody), MimeType: res.Header.Get("Content-Type")}
+	if utf8.Valid(resBody) {
+		entry.Response.Content.Text = string(resBody)
+	} else {
+		entry.Response.Content.Text = base64.StdEncoding.EncodeToString(resBody)
+		entry.Response.Content.Encoding = "base64"
+	}
+	return entry
+}
+
+// harHeaders は認証情報を伏せたヘッダを名前の順に返す
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+				value = redactedValue
+			}
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(headers, func(i, j int) bool {
+		return headers[i].Name < headers[j].Name
+	})
+	return headers
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithHARFile(t *testing.T) {
+	_, serverURL := newOptionsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			fmt.Fprint(w, string(body))
+			return
+		}
+		fmt.Fprint(w, `{"members":[]}`)
+	})
+
+	path := filepath.Join(t.TempDir(), "trace.har")
+	newClient := func() *Client {
+		return NewClient("test", "username", "apikey-secret", "owner", "blog.example.com",
+			WithHARFile(path), WithBaseURL(serverURL), WithCache(false))
+	}
+
+	// 同じファイルに書き込む複数のクライアントから並行してリクエストしても、エントリーを失わない
+	clients := []*Client{newClient(), newClient()}
+	const requests = 10
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			if _, err := c.ListMembers(context.Background()); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}(clients[i%len(clients)])
+	}
+	wg.Wait()
+	if _, err := clients[0].AddMember(context.Background(), "member", "editor"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Contains(string(buf), "PasswordDigest") || strings.Contains(string(buf), "apikey-secret") {
+		t.Errorf("credentials should not be written: %s", buf)
+	}
+
+	var har harDocument
+	if err := json.Unmarshal(buf, &har); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if har.Log.Version != "1.2" || har.Log.Creator.Name != "terraform-provider-hatenablog-members" || har.Log.Creator.Version != "test" {
+		t.Errorf("unexpected log: %+v", har.Log)
+	}
+	if len(har.Log.Entries) != requests+1 {
+		t.Fatalf("got %d entries, want %d", len(har.Log.Entries), requests+1)
+	}
+
+	entry := har.Log.Entries[requests]
+	if entry.Request.Method != http.MethodPost || entry.Request.PostData == nil || entry.Request.PostData.Text == "" {
+		t.Errorf("unexpected request: %+v", entry.Request)
+	}
+	if entry.Response.Status != http.StatusOK || entry.Response.StatusText != "OK" || entry.Response.Content.Text != entry.Request.PostData.Text {
+		t.Errorf("unexpected response: %+v", entry.Response)
+	}
+	var wsse bool
+	for _, header := range entry.Request.Headers {
+		if header.Name == "X-Wsse" {
+			wsse = header.Value == redactedValue
+		}
+	}
+	if !wsse {
+		t.Errorf("X-WSSE header should be redacted: %+v", entry.Request.Headers)
+	}
+}
+
+func TestWithHARFile_Error(t *testing.T) {
+	_, serverURL := newOptionsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"members":[]}`)
+	})
+
+	path := filepath.Join(t.TempDir(), "trace.har")
+	if err := os.WriteFile(path, []byte("not a HAR file"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// HAR ファイルに書き込めなくてもリクエストは失敗させない
+	var log bytes.Buffer
+	client := NewClient("test", "username", "apikey", "owner", "blog.example.com",
+		WithHARFile(path), WithBaseURL(serverURL), WithLogger(slog.New(slog.NewTextHandler(&log, nil))))
+	if _, err := client.ListMembers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(log.String(), "is not a HAR file") {
+		t.Errorf("unexpected log: %s", log.String())
+	}
+}
+
+func TestHARFile_append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.har")
+	// 他のツールが書き出した HAR にも追記できる
+	existing := `{"log":{"version":"1.2","creator":{"name":"other","version":"1"},"entries":[{"startedDateTime":"2024-01-01T00:00:00Z"}]}}`
+	if err := os.WriteFile(path, []byte(existing), 0o600); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	f := openHARFile(path)
+	creator := harCreator{Name: "terraform-provider-hatenablog-members", Version: "test"}
+	for i := 0; i < 3; i++ {
+		if err := f.append(harEntry{StartedDateTime: fmt.Sprintf("2024-01-01T00:00:0%dZ", i+1)}, creator); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	// 一度書き出した後は、ファイルを読み直さずに末尾に追記する
+	appended, err := f.appendInPlace(harEntry{StartedDateTime: "2024-01-01T00:00:04Z"})
+	if err != nil || !appended {
+		t.Fatalf("expected the entry to be appended in place: %v, %s", appended, err)
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var har harDocument
+	if err := json.Unmarshal(buf, &har); err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, buf)
+	}
+	if har.Log.Creator.Name != "other" || len(har.Log.Entries) != 5 {
+		t.Fatalf("unexpected log: %+v", har.Log)
+	}
+	for i, entry := range har.Log.Entries {
+		if want := fmt.Sprintf("2024-01-01T00:00:0%dZ", i); entry.StartedDateTime != want {
+			t.Errorf("entry %d: got %s, want %s", i, entry.StartedDateTime, want)
+		}
+	}
+}
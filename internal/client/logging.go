@@ -0,0 +1,103 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LevelTrace is the level below slog.LevelDebug at which the client logs
+// the headers and the bodies of requests to the API.
+const LevelTrace = slog.LevelDebug - 4
+
+// maxLoggedBodySize はログに出力する本文の最大のバイト数
+const maxLoggedBodySize = 4096
+
+// redactedValue は認証情報の代わりにログに出力する値
+const redactedValue = "***"
+
+// sensitiveHeaders は値をログに出力しないヘッダ
+var sensitiveHeaders = map[string]bool{
+	"X-Wsse":              true,
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// logHTTPRequest はAPIへのリクエストとレスポンスをログに出力する
+// sent は実際に送信した署名済みのリクエスト
+func (t *transport) logHTTPRequest(ctx context.Context, req, sent *http.Request, res *http.Response, body []byte, duration time.Duration) {
+	t.logger.DebugContext(ctx, "request to the Hatena Blog API",
+		"method", req.Method, "url", req.URL.Redacted(), "status", res.StatusCode, "duration", duration, "response_size", len(body))
+
+	if !t.logger.Enabled(ctx, LevelTrace) {
+		return
+	}
+	t.logger.Log(ctx, LevelTrace, "request to the Hatena Blog API with headers and bodies",
+		"method", req.Method, "url", req.URL.Redacted(), "status", res.StatusCode,
+		"request_headers", redactedHeaders(sent.Header), "request_body", truncatedBody(requestBody(req)),
+		"response_headers", redactedHeaders(res.Header), "response_body", truncatedBody(body))
+}
+
+// redactedHeaders は認証情報を伏せたヘッダを "Name: value" の行にして返す
+func redactedHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range header[name] {
+			if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+				value = redactedValue
+			}
+			lines = append(lines, name+": "+value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// requestBody はリクエストの本文を読み直して返す
+// 本文がないときや読み直せないときは nil を返す
+func requestBody(req *http.Request) []byte {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil
+	}
+	return buf
+}
+
+// responseBody はレスポンスの本文を読み込んで返し、読み直せるように本文を差し替える
+func responseBody(res *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// truncatedBody は本文を最大 maxLoggedBodySize バイトまでに切り詰めて返す
+func truncatedBody(body []byte) string {
+	if len(body) <= maxLoggedBodySize {
+		return string(body)
+	}
+	return string(body[:maxLoggedBodySize]) + "...(truncated)"
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OAuth1Authenticator authenticates requests with OAuth 1.0a (RFC 5849) using HMAC-SHA1,
+// with the consumer key of an application and the access token issued to the operator.
+type OAuth1Authenticator struct {
+	ConsumerKey    string
+	ConsumerSecret string
+	Token          string
+	TokenSecret    string
+
+	// テストで値を固定するために差し替える
+	nowFunc   func() time.Time
+	nonceFunc func() (string, error)
+}
+
+// ensure that OAuth1Authenticator satisfies interfaces
+var _ Authenticator = &OAuth1Authenticator{}
+
+func (a *OAuth1Authenticator) Transport(base http.RoundTripper) http.RoundTripper {
+	return &oauth1Transport{authenticator: a, base: base}
+}
+
+// oauth1Transport は Authorization ヘッダに OAuth の署名を付与する
+type oauth1Transport struct {
+	authenticator *OAuth1Authenticator
+	base          http.RoundTripper
+}
+
+func (t *oauth1Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	authorization, err := t.authenticator.authorization(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// RoundTripper はリクエストを変更してはいけないので複製してからヘッダを付与する
+	signed := req.Clone(req.Context())
+	signed.Header.Set("Authorization", authorization)
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
+
+// authorization はリクエストに署名した Authorization ヘッダの値を返す
+func (a *OAuth1Authenticator) authorization(req *http.Request) (string, error) {
+	now := time.Now
+	if a.nowFunc != nil {
+		now = a.nowFunc
+	}
+	newNonce := randomNonce
+	if a.nonceFunc != nil {
+		newNonce = a.nonceFunc
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return "", err
+	}
+
+	oauthParams := map[string]string{
+		"oauth_consumer_key":     a.ConsumerKey,
+		"oauth_nonce":            nonce,
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(now().Unix(), 10),
+		"oauth_token":            a.Token,
+		"oauth_version":          "1.0",
+	}
+
+	params, err := requestParams(req)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range oauthParams {
+		params.Add(k, v)
+	}
+	oauthParams["oauth_signature"] = a.signature(req.Method, req.URL, params)
+
+	keys := make([]string, 0, len(oauthParams))
+	for k := range oauthParams {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, oauthEscape(k), oauthEscape(oauthParams[k])))
+	}
+	return "OAuth " + strings.Join(pairs, ", "), nil
+}
+
+// signature は RFC 5849 3.4.2 の HMAC-SHA1 による署名を返す
+func (a *OAuth1Authenticator) signature(method string, u *url.URL, params url.Values) string {
+	key := oauthEscape(a.ConsumerSecret) + "&" + oauthEscape(a.TokenSecret)
+	mac := hmac.New(sha1.New, []byte(key))
+	mac.Write([]byte(signatureBaseString(method, u, params)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signatureBaseString は RFC 5849 3.4.1 の署名対象の文字列を返す
+func signatureBaseString(method string, u *url.URL, params url.Values) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Host)
+	// デフォルトのポートは含めない
+	if (scheme == "http" && u.Port() == "80") || (scheme == "https" && u.Port() == "443") {
+		host = strings.ToLower(u.Hostname())
+	}
+	// パスはリクエストで送るエスケープされた形のまま使う。url.URL に戻すと二重にエスケープされる
+	baseURL := scheme + "://" + host + u.EscapedPath()
+
+	pairs := make([]string, 0, len(params))
+	for k, vs := range params {
+		for _, v := range vs {
+			pairs = append(pairs, oauthEscape(k)+"="+oauthEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		oauthEscape(baseURL),
+		oauthEscape(strings.Join(pairs, "&")),
+	}, "&")
+}
+
+// requestParams は署名に含めるクエリとフォームのパラメータを返す
+// JSONなどフォーム以外の本文は署名に含めない
+func requestParams(req *http.Request) (url.Values, error) {
+	params := url.Values{}
+	for k, vs := range req.URL.Query() {
+		params[k] = append(params[k], vs...)
+	}
+
+	if req.Body == nil || req.GetBody == nil || req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		return params, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	form, err := url.ParseQuery(string(buf))
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range form {
+		params[k] = append(params[k], vs...)
+	}
+	return params, nil
+}
+
+// oauthEscape は RFC 5849 3.6 に従って文字列をエンコードする
+// 英数字と "-._~" 以外をすべて %XX の形式にする
+func oauthEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func randomNonce() (string, error) {
+	nonce, err := randomBytes()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
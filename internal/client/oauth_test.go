@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// OAuth 1.0a の署名の例として広く使われているリクエストで、署名が一致することを確かめる
+func TestOAuth1Authenticator_authorization(t *testing.T) {
+	a := &OAuth1Authenticator{
+		ConsumerKey:    "xvz1evFS4wEEPTGEFPHBog",
+		ConsumerSecret: "kAcSOqF21Fu85e7zjz7ZN2U4ZRhfV3WpwPAoE3Z7kBw",
+		Token:          "370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb",
+		TokenSecret:    "LswwdoUaIvS8ltyTt5jkRh4J50vUPVVHtR2YPi5kE",
+		nowFunc:        func() time.Time { return time.Unix(1318622958, 0) },
+		nonceFunc:      func() (string, error) { return "kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg", nil },
+	}
+
+	body := "status=Hello%20Ladies%20%2b%20Gentlemen%2c%20a%20signed%20OAuth%20request%21"
+	req, err := http.NewRequest("POST", "https://api.twitter.com/1.1/statuses/update.json?include_entities=true", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	authorization, err := a.authorization(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(authorization, `oauth_signature="hCtSmYh%2BiHYCEqBWrE7C7hYmtUk%3D"`) {
+		t.Errorf("unexpected authorization: %s", authorization)
+	}
+}
+
+func TestSignatureBaseString(t *testing.T) {
+	tests := map[string]struct {
+		url  string
+		want string
+	}{
+		"default port": {
+			url:  "HTTPS://Blog.Hatena.ne.jp:443/owner/blog.example.com/api/members",
+			want: "GET&https%3A%2F%2Fblog.hatena.ne.jp%2Fowner%2Fblog.example.com%2Fapi%2Fmembers&",
+		},
+		"other port": {
+			url:  "http://localhost:8080/api/members",
+			want: "GET&http%3A%2F%2Flocalhost%3A8080%2Fapi%2Fmembers&",
+		},
+		// エスケープされたパスは二重にエスケープしない
+		"escaped path": {
+			url:  "https://blog.hatena.ne.jp/owner/blog.example.com/api/members/a%20b",
+			want: "GET&https%3A%2F%2Fblog.hatena.ne.jp%2Fowner%2Fblog.example.com%2Fapi%2Fmembers%2Fa%2520b&",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := signatureBaseString("GET", u, url.Values{}); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOAuth1Authenticator_Transport(t *testing.T) {
+	a := &OAuth1Authenticator{
+		ConsumerKey:    "consumer",
+		ConsumerSecret: "consumer secret",
+		Token:          "token",
+		TokenSecret:    "token secret",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := verifyOAuth1(r, a.ConsumerSecret, a.TokenSecret); err != nil {
+			t.Errorf("invalid signature: %s", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-WSSE") != "" {
+			t.Errorf("unexpected X-WSSE header")
+		}
+		fmt.Fprint(w, `{"members":[]}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("test", "username", "", "owner", "blog.example.com", WithAuthenticator(a))
+	if err := client.SetEndpoint(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ListMembers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := client.DeleteMember(context.Background(), "member name"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// 秘密鍵が異なると署名の検証に失敗する
+	wrong := *a
+	wrong.TokenSecret = "wrong"
+	client = NewClient("test", "username", "", "owner", "blog.example.com", WithAuthenticator(&wrong))
+	if err := client.SetEndpoint(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := verifyOAuth1(r, a.ConsumerSecret, a.TokenSecret); err == nil {
+			t.Errorf("signature with a wrong secret should not be valid")
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	if _, err := client.ListMembers(context.Background()); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+var oauthParamPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// verifyOAuth1 はサーバー側で Authorization ヘッダの署名を検証する
+func verifyOAuth1(r *http.Request, consumerSecret, tokenSecret string) error {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "OAuth ") {
+		return fmt.Errorf("missing OAuth authorization: %q", authorization)
+	}
+
+	params := url.Values{}
+	var signature string
+	for _, m := range oauthParamPattern.FindAllStringSubmatch(authorization, -1) {
+		v, err := url.PathUnescape(m[2])
+		if err != nil {
+			return err
+		}
+		if m[1] == "oauth_signature" {
+			signature = v
+			continue
+		}
+		params.Set(m[1], v)
+	}
+	for k, vs := range r.URL.Query() {
+		params[k] = append(params[k], vs...)
+	}
+	if params.Get("oauth_signature_method") != "HMAC-SHA1" || params.Get("oauth_nonce") == "" || params.Get("oauth_timestamp") == "" {
+		return fmt.Errorf("missing OAuth parameters: %v", params)
+	}
+
+	u := *r.URL
+	u.Scheme = "http"
+	u.Host = r.Host
+	want := (&OAuth1Authenticator{ConsumerSecret: consumerSecret, TokenSecret: tokenSecret}).signature(r.Method, &u, params)
+	if !hmac.Equal([]byte(signature), []byte(want)) {
+		return fmt.Errorf("signature mismatch: %s", signature)
+	}
+	return nil
+}
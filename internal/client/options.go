@@ -0,0 +1,113 @@
+package client
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Option configures a Client created by NewClient.
+type Option func(*options)
+
+// options は NewClient に渡されたオプションをまとめたもの
+type options struct {
+	httpClient      *http.Client
+	transport       http.RoundTripper
+	baseURL         *url.URL
+	userAgentSuffix string
+	logger          *slog.Logger
+	cache           bool
+	authenticator   Authenticator
+	harFile         *harFile
+}
+
+func defaultOptions() options {
+	return options{
+		logger: slog.New(slog.DiscardHandler),
+		cache:  true,
+	}
+}
+
+// WithHTTPClient makes the client send requests with a copy of httpClient.
+// The X-WSSE and User-Agent headers are added on top of its Transport.
+// Unless httpClient has its own CheckRedirect, redirects are not followed
+// so that a redirect to the login page can be reported as ErrLoginRedirect.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithBaseURL sets the URL of the Hatena Blog API, like SetEndpoint without validation.
+// The query and the fragment of the URL are ignored.
+func WithBaseURL(baseURL *url.URL) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithTransport sets the http.RoundTripper which sends the requests signed with the X-WSSE header,
+// e.g. a middleware for tracing or recording.
+// It takes precedence over the Transport of the client given by WithHTTPClient.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithUserAgentSuffix appends suffix to the User-Agent header, e.g. the name of the tool using the client.
+func WithUserAgentSuffix(suffix string) Option {
+	return func(o *options) {
+		o.userAgentSuffix = suffix
+	}
+}
+
+// WithLogger makes the client log each request to the API at the debug level,
+// with the method, the URL, the status, the duration and the size of the response.
+// At LevelTrace, the headers and the bodies truncated to 4 KiB are logged as well.
+// The values of the X-WSSE, Authorization and Cookie headers are never logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithHARFile makes the client append each exchange with the API to the file at path in HAR 1.2 format,
+// e.g. to attach to a report to Hatena or to replay locally.
+// The file is created if it does not exist.
+// The values of the X-WSSE, Authorization and Cookie headers are replaced with "***".
+// Clients writing to the same file within a process take turns, so no entry is lost under concurrent requests.
+// The file is not locked, so it must not be written by more than one process at a time.
+// Failures to write the file are logged as warnings and do not fail the requests.
+func WithHARFile(path string) Option {
+	return func(o *options) {
+		o.harFile = openHARFile(path)
+	}
+}
+
+// WithCache sets whether ListMembers returns the members fetched last time
+// until the client changes the members. Defaults to true.
+// Disable it for long-lived clients where others may change the members.
+func WithCache(enabled bool) Option {
+	return func(o *options) {
+		o.cache = enabled
+	}
+}
+
+// WithAuthenticator sets how the client authenticates requests, e.g. with OAuth1Authenticator.
+// Defaults to WSSEAuthenticator with the username and the API key given to NewClient.
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(o *options) {
+		o.authenticator = authenticator
+	}
+}
+
+// normalizeBaseURL はAPIのURLからクエリなどを除き、末尾の "/" を取り除く
+func normalizeBaseURL(u *url.URL) url.URL {
+	return url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   strings.TrimSuffix(u.Path, "/"),
+	}
+}
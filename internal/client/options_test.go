@@ -0,0 +1,238 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripperFunc は関数を http.RoundTripper として使うためのアダプタ
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newOptionsTestServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *url.URL) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, serverURL
+}
+
+func TestWithBaseURL(t *testing.T) {
+	baseURL, _ := url.Parse("http://localhost:8080/staging/?debug=1")
+	client := NewClient("test", "username", "apikey", "owner", "blog.example.com", WithBaseURL(baseURL))
+
+	if got := client.Endpoint(); got != "http://localhost:8080/staging/owner/blog.example.com/api" {
+		t.Errorf("unexpected endpoint: %s", got)
+	}
+}
+
+func TestNewClient_DefaultEndpoint(t *testing.T) {
+	client := NewClient("test", "username", "apikey", "owner", "blog.example.com")
+
+	if got, want := client.Endpoint(), DefaultEndpoint+"/owner/blog.example.com/api"; got != want {
+		t.Errorf("unexpected endpoint: %s, want %s", got, want)
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	_, serverURL := newOptionsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assertRequest(t, r, "GET")
+		http.Redirect(w, r, "https://www.hatena.ne.jp/login", http.StatusFound)
+	})
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	client := NewClient("test", "username", "apikey", "owner", "blog.example.com", WithHTTPClient(httpClient), WithBaseURL(serverURL))
+
+	if httpClient.Transport != nil || httpClient.CheckRedirect != nil {
+		t.Errorf("the given client should not be modified")
+	}
+	if client.RequestTimeout() != 30*time.Second {
+		t.Errorf("unexpected request timeout: %s", client.RequestTimeout())
+	}
+
+	// リダイレクトをたどらずにエラーにする
+	if _, err := client.ListMembers(context.Background()); !errors.Is(err, ErrLoginRedirect) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWithTransport(t *testing.T) {
+	_, serverURL := newOptionsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"members":[]}`)
+	})
+
+	var signed bool
+	middleware := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// 独自のトランスポートには署名済みのリクエストが渡される
+		signed = req.Header.Get("X-WSSE") != ""
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	client := NewClient("test", "username", "apikey", "owner", "blog.example.com", WithTransport(middleware), WithBaseURL(serverURL))
+
+	if _, err := client.ListMembers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !signed {
+		t.Errorf("the request should be signed before the transport")
+	}
+
+	if err := client.SetProxy("http://proxy.example.com:8080"); err == nil {
+		t.Errorf("custom transport should not be configured")
+	}
+	if err := client.SetCACerts(nil); err == nil {
+		t.Errorf("custom transport should not be configured")
+	}
+}
+
+func TestWithTransport_BareResponse(t *testing.T) {
+	// http.Client は res.Request を設定しないので、独自のトランスポートが設定しなくてもエラーを返す
+	bare := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Body:       io.NopCloser(strings.NewReader("internal error")),
+		}, nil
+	})
+	client := NewClient("test", "username", "apikey", "owner", "blog.example.com", WithTransport(bare))
+	ctx := context.Background()
+
+	assertAPIError := func(t *testing.T, err error, method, u string) {
+		t.Helper()
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if apiErr.Method != method || apiErr.URL != u || apiErr.StatusCode != http.StatusInternalServerError || apiErr.Body != "internal error" {
+			t.Errorf("unexpected error: %+v", apiErr)
+		}
+	}
+
+	_, err := client.ListMembers(ctx)
+	assertAPIError(t, err, "GET", "https://blog.hatena.ne.jp/owner/blog.example.com/api/members")
+	_, err = client.AddMember(ctx, "member", "editor")
+	assertAPIError(t, err, "POST", "https://blog.hatena.ne.jp/owner/blog.example.com/api/members")
+	err = client.DeleteMember(ctx, "member")
+	assertAPIError(t, err, "DELETE", "https://blog.hatena.ne.jp/owner/blog.example.com/api/members/member")
+}
+
+func TestWithUserAgentSuffix(t *testing.T) {
+	_, serverURL := newOptionsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assertRequest(t, r, "GET")
+		if !strings.HasSuffix(r.UserAgent(), " my-tool/1.0") {
+			t.Errorf("unexpected User-Agent: %s", r.UserAgent())
+		}
+		fmt.Fprint(w, `{"members":[]}`)
+	})
+
+	client := NewClient("test", "username", "apikey", "owner", "blog.example.com", WithUserAgentSuffix("my-tool/1.0"), WithBaseURL(serverURL))
+	if _, err := client.ListMembers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	_, serverURL := newOptionsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"members":[]}`)
+	})
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient("test", "username", "apikey", "owner", "blog.example.com", WithLogger(logger), WithBaseURL(serverURL))
+	if _, err := client.ListMembers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	log := buf.String()
+	if !strings.Contains(log, "method=GET") || !strings.Contains(log, "status=200") {
+		t.Errorf("unexpected log: %s", log)
+	}
+	if strings.Contains(log, "PasswordDigest") {
+		t.Errorf("credentials should not be logged: %s", log)
+	}
+}
+
+func TestWithLogger_Trace(t *testing.T) {
+	body := strings.Repeat("x", maxLoggedBodySize+1)
+	_, serverURL := newOptionsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "cookie-secret"})
+		fmt.Fprintf(w, `{"members":[],"padding":"%s"}`, body)
+	})
+
+	for name, authenticator := range map[string]Authenticator{
+		"wsse":  &WSSEAuthenticator{Username: "username", APIKey: "apikey-secret"},
+		"oauth": &OAuth1Authenticator{ConsumerKey: "consumer-key", ConsumerSecret: "consumer-secret", Token: "token-secret", TokenSecret: "token-secret-secret"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: LevelTrace}))
+			client := NewClient("test", "username", "apikey-secret", "owner", "blog.example.com",
+				WithAuthenticator(authenticator), WithLogger(logger), WithBaseURL(serverURL), WithCache(false))
+			if _, err := client.ListMembers(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			log := buf.String()
+			for _, want := range []string{"response_size=", "response_body=", "...(truncated)", "Set-Cookie: ***"} {
+				if !strings.Contains(log, want) {
+					t.Errorf("log should contain %q: %s", want, log)
+				}
+			}
+			if name == "wsse" && !strings.Contains(log, "X-Wsse: ***") {
+				t.Errorf("X-WSSE header should be masked: %s", log)
+			}
+			if name == "oauth" && !strings.Contains(log, "Authorization: ***") {
+				t.Errorf("Authorization header should be masked: %s", log)
+			}
+			for _, secret := range []string{"secret", "PasswordDigest", "oauth_signature", "consumer-key"} {
+				if strings.Contains(log, secret) {
+					t.Errorf("%q should not be logged: %s", secret, log)
+				}
+			}
+		})
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	var requests int
+	_, serverURL := newOptionsTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"members":[]}`)
+	})
+
+	for _, tt := range []struct {
+		enabled      bool
+		wantRequests int
+	}{
+		{enabled: true, wantRequests: 1},
+		{enabled: false, wantRequests: 2},
+	} {
+		t.Run(fmt.Sprint(tt.enabled), func(t *testing.T) {
+			requests = 0
+			client := NewClient("test", "username", "apikey", "owner", "blog.example.com", WithCache(tt.enabled), WithBaseURL(serverURL))
+			for range 2 {
+				if _, err := client.ListMembers(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("unexpected number of requests: %d", requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+// recorder パッケージはAPIとのやりとりをYAMLのカセットに記録し、テストで再生するための http.RoundTripper です
+// 記録するときは X-WSSE などの認証情報を含むヘッダを保存せず、指定された秘密の文字列を伏せます
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mode is whether a Recorder records the interactions with the API or replays them.
+type Mode int
+
+const (
+	// ModeReplay serves the responses recorded in the cassette without sending any request.
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests to the API and records the interactions to the cassette.
+	ModeRecord
+	// ModeLive sends the requests to the API without recording them, e.g. to check that the API still behaves as recorded.
+	// ForTest also uses it when the cassette has not been recorded, so that the test runs against the API.
+	ModeLive
+)
+
+// ModeEnv is the environment variable which selects the mode of the Recorders created by ModeFromEnv.
+const ModeEnv = "HATENABLOG_CASSETTE_MODE"
+
+// ModeFromEnv returns ModeRecord if the environment variable HATENABLOG_CASSETTE_MODE is "record",
+// ModeLive if it is "live", and ModeReplay otherwise.
+func ModeFromEnv() Mode {
+	switch os.Getenv(ModeEnv) {
+	case "record":
+		return ModeRecord
+	case "live":
+		return ModeLive
+	}
+	return ModeReplay
+}
+
+// redactedValue は秘密の文字列の代わりにカセットに保存する値
+const redactedValue = "***"
+
+// recordedHeaders はカセットに保存するヘッダ
+// 認証情報や実行のたびに変わる Date などのヘッダは保存しない
+var recordedHeaders = []string{"Content-Type", "Location"}
+
+// Recorder is an http.RoundTripper which records the interactions with the API to a cassette,
+// or replays them from the cassette.
+//
+// In ModeReplay, a request is served with the first unused interaction matching its method, path and body.
+// If all the matching interactions have been used, the request fails,
+// so that a test sending more requests than recorded does not pass on stale responses.
+type Recorder struct {
+	path    string
+	mode    Mode
+	base    http.RoundTripper
+	secrets []string
+
+	mu       sync.Mutex
+	cassette cassette
+	// used は再生したインタラクション
+	used []bool
+}
+
+// ensure that Recorder satisfies interfaces
+var _ http.RoundTripper = &Recorder{}
+
+// New creates a Recorder for the cassette at path.
+// In ModeRecord and ModeLive, the requests are sent through base, or http.DefaultTransport if base is nil.
+// In ModeRecord, secrets are replaced with "***" in the cassette.
+// In ModeReplay, the cassette must exist.
+func New(path string, mode Mode, base http.RoundTripper, secrets ...string) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, base: base}
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	if mode != ModeReplay {
+		return r, nil
+	}
+
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("cassette %s does not exist; record it with %s=record: %w", path, ModeEnv, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(buf, &r.cassette); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// ForTest creates a Recorder for the cassette at path in the mode given by ModeFromEnv,
+// which saves the cassette at the end of the test in ModeRecord.
+// The cassette is not saved if the test fails or is skipped, so that a good cassette is not overwritten.
+// If the cassette has not been recorded, it creates a Recorder in ModeLive instead of ModeReplay.
+func ForTest(t testing.TB, path string, secrets ...string) *Recorder {
+	t.Helper()
+
+	mode := ModeFromEnv()
+	if _, err := os.Stat(path); mode == ModeReplay && errors.Is(err, fs.ErrNotExist) {
+		mode = ModeLive
+	}
+	r, err := New(path, mode, nil, secrets...)
+	if err != nil {
+		t.Fatalf("unable to load the cassette: %s", err)
+	}
+	t.Cleanup(func() {
+		if t.Failed() || t.Skipped() {
+			return
+		}
+		if err := r.Stop(); err != nil {
+			t.Errorf("unable to save the cassette: %s", err)
+		}
+	})
+	return r
+}
+
+// Mode returns whether the Recorder records or replays the interactions.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Stop saves the recorded interactions to the cassette in ModeRecord. It does nothing in ModeReplay.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&r.cassette); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, buf.Bytes(), 0o644)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeLive {
+		return r.transport().RoundTrip(req)
+	}
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+// record はリクエストを送信し、秘密の文字列を伏せたインタラクションをカセットに追加する
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	// 本文を読み込んだので、送信するリクエストには読み直せる本文を付ける
+	if req.Body != nil {
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	res, err := r.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	u := *req.URL
+	u.User = nil
+	in := interaction{
+		Request: request{
+			Method:  req.Method,
+			URL:     r.scrub(u.String()),
+			Headers: r.headers(req.Header),
+			Body:    r.scrub(string(body)),
+		},
+		Response: response{
+			Status:  res.StatusCode,
+			Headers: r.headers(res.Header),
+			Body:    r.scrub(string(resBody)),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	return res, nil
+}
+
+// replay はリクエストにマッチするインタラクションのレスポンスを返す
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matched := false
+	for i, in := range r.cassette.Interactions {
+		if !in.Request.matches(req, body) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return in.Response.httpResponse(req), nil
+		}
+		matched = true
+	}
+	if matched {
+		return nil, fmt.Errorf("all the interactions in cassette %s matching %s %s have been used; record it again with %s=record", r.path, req.Method, req.URL.Path, ModeEnv)
+	}
+	return nil, fmt.Errorf("no interaction in cassette %s matches %s %s", r.path, req.Method, req.URL.Path)
+}
+
+// transport はリクエストを送信する http.RoundTripper を返す
+func (r *Recorder) transport() http.RoundTripper {
+	if r.base == nil {
+		return http.DefaultTransport
+	}
+	return r.base
+}
+
+// headers はカセットに保存するヘッダを返す
+func (r *Recorder) headers(header http.Header) map[string][]string {
+	headers := map[string][]string{}
+	for _, name := range recordedHeaders {
+		for _, value := range header.Values(name) {
+			headers[name] = append(headers[name], r.scrub(value))
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// scrub は秘密の文字列を伏せる
+func (r *Recorder) scrub(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+	return s
+}
+
+// requestBody はリクエストの本文を返す。本文がないときは nil を返す
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return io.ReadAll(req.Body)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// cassette はカセットのファイルの形式
+type cassette struct {
+	Interactions []interaction `yaml:"interactions"`
+}
+
+type interaction struct {
+	Request  request  `yaml:"request"`
+	Response response `yaml:"response"`
+}
+
+type request struct {
+	Method  string              `yaml:"method"`
+	URL     string              `yaml:"url"`
+	Headers map[string][]string `yaml:"headers,omitempty"`
+	Body    string              `yaml:"body,omitempty"`
+}
+
+type response struct {
+	Status  int                 `yaml:"status"`
+	Headers map[string][]string `yaml:"headers,omitempty"`
+	Body    string              `yaml:"body,omitempty"`
+}
+
+// matches はメソッド、パス、本文がリクエストと一致するかどうかを返す
+func (r request) matches(req *http.Request, body []byte) bool {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return false
+	}
+	return r.Method == req.Method && u.Path == req.URL.Path && r.Body == string(body)
+}
+
+func (r response) httpResponse(req *http.Request) *http.Response {
+	header := http.Header{}
+	for name, values := range r.Headers {
+		for _, value := range values {
+			header.Add(name, value)
+		}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,160 @@
+package recorder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret-cookie")
+		w.Header().Set("X-Request", r.Method+" "+string(body))
+		io.WriteString(w, `{"n":"`+strings.Repeat("x", requests)+`","apikey":"secret-apikey"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "cassettes", "test.yaml")
+	send := func(t *testing.T, rt http.RoundTripper, method, p, body string) (*http.Response, string) {
+		t.Helper()
+		var r io.Reader
+		if body != "" {
+			r = strings.NewReader(body)
+		}
+		req, err := http.NewRequest(method, server.URL+p, r)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		req.Header.Set("X-WSSE", `UsernameToken Username="user", PasswordDigest="digest"`)
+		res, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer res.Body.Close()
+		buf, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return res, string(buf)
+	}
+
+	recorder, err := New(path, ModeRecord, nil, "secret-apikey")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_, first := send(t, recorder, http.MethodGet, "/members", "")
+	send(t, recorder, http.MethodGet, "/members", "")
+	send(t, recorder, http.MethodPost, "/members", `{"username":"a"}`)
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if first != `{"n":"x","apikey":"secret-apikey"}` {
+		t.Errorf("the response should be returned as is while recording: %s", first)
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, secret := range []string{"secret-apikey", "secret-cookie", "PasswordDigest", "X-Request"} {
+		if strings.Contains(string(buf), secret) {
+			t.Errorf("%q should not be recorded: %s", secret, buf)
+		}
+	}
+
+	recorder, err = New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	requests = 0
+	tests := []struct {
+		method, path, body string
+		want               string
+	}{
+		{method: http.MethodGet, path: "/members", want: `{"n":"x","apikey":"***"}`},
+		{method: http.MethodPost, path: "/members", body: `{"username":"a"}`, want: `{"n":"xxx","apikey":"***"}`},
+		{method: http.MethodGet, path: "/members", want: `{"n":"xx","apikey":"***"}`},
+	}
+	for _, tt := range tests {
+		res, body := send(t, recorder, tt.method, tt.path, tt.body)
+		if body != tt.want || res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s: unexpected response: %d %v %s", tt.method, tt.path, res.StatusCode, res.Header, body)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("no request should be sent while replaying: %d", requests)
+	}
+
+	// 記録したより多くリクエストしたときは、古いレスポンスを返さずに失敗する
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/members", nil)
+	if _, err := recorder.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "have been used") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, tt := range []struct{ method, path, body string }{
+		{method: http.MethodDelete, path: "/members"},
+		{method: http.MethodGet, path: "/other"},
+		{method: http.MethodPost, path: "/members", body: `{"username":"b"}`},
+	} {
+		req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+		if _, err := recorder.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "no interaction") {
+			t.Errorf("%s %s %s: unexpected error: %v", tt.method, tt.path, tt.body, err)
+		}
+	}
+}
+
+func TestNew_MissingCassette(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.yaml"), ModeReplay, nil)
+	if err == nil || !strings.Contains(err.Error(), ModeEnv+"=record") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestForTest_MissingCassette(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+
+	// カセットがないときは記録せずにAPIにアクセスする
+	t.Setenv(ModeEnv, "")
+	path := filepath.Join(t.TempDir(), "missing.yaml")
+	recorder := ForTest(t, path)
+	if recorder.Mode() != ModeLive {
+		t.Fatalf("unexpected mode: %d", recorder.Mode())
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	res, err := recorder.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	res.Body.Close()
+	if requests != 1 {
+		t.Errorf("the request should be sent: %d", requests)
+	}
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Errorf("the cassette should not be saved")
+	}
+}
+
+func TestModeFromEnv(t *testing.T) {
+	for env, want := range map[string]Mode{"": ModeReplay, "replay": ModeReplay, "record": ModeRecord, "live": ModeLive} {
+		t.Setenv(ModeEnv, env)
+		if got := ModeFromEnv(); got != want {
+			t.Errorf("%s=%q: got %d, want %d", ModeEnv, env, got, want)
+		}
+	}
+}
@@ -2,32 +2,121 @@ package client
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
-
-	"github.com/motemen/go-wsse"
+	"sync/atomic"
+	"time"
 )
 
-// transport wraps wsse.Transport to set X-WSSE header.
-// Additionally, it sets User-Agent header
+// transport wraps the transport of an Authenticator to add the credentials.
+// Additionally, it sets User-Agent header and logs requests with the credentials masked
 type transport struct {
-	Transport wsse.Transport
+	Transport     http.RoundTripper
+	authenticator Authenticator
+
+	userAgent string
+	version   string
+	logger    *slog.Logger
+	// har はAPIとのやりとりを記録する HAR ファイル。記録しないときは nil
+	har *harFile
 
-	version string
+	// clockSkew は最後に受け取ったレスポンスの Date ヘッダから求めた、ローカルの時計に対するサーバーの時計の進み
+	clockSkew    atomic.Int64
+	hasClockSkew atomic.Bool
 }
 
-func newTransport(username, apikey, version string) *transport {
+func newTransport(authenticator Authenticator, base http.RoundTripper, version, userAgentSuffix string, logger *slog.Logger, har *harFile) *transport {
+	userAgent := fmt.Sprintf("terraform-provider-hatenablog-members/%s (+https://github.com/hatena/terraform-provider-hatenablog-members)", version)
+	if userAgentSuffix != "" {
+		userAgent += " " + userAgentSuffix
+	}
 	return &transport{
-		Transport: wsse.Transport{
-			Username: username,
-			Password: apikey,
-		},
-		version: version,
+		Transport:     authenticator.Transport(base),
+		authenticator: authenticator,
+		userAgent:     userAgent,
+		version:       version,
+		logger:        logger,
+		har:           har,
 	}
 }
 
 func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
-	ua := fmt.Sprintf("terraform-provider-hatenablog-members/%s (+https://github.com/hatena/terraform-provider-hatenablog-members)", t.version)
-	req.Header.Set("User-Agent", ua)
+	req.Header.Set("User-Agent", t.userAgent)
+
+	offset := clockOffset(t.authenticator)
+	keyIndex := apiKeyIndex(t.authenticator)
+	start := time.Now()
+	res, err := t.Transport.RoundTrip(req)
+	duration := time.Since(start)
+	if err != nil {
+		t.logger.DebugContext(req.Context(), "request to the Hatena Blog API failed",
+			"method", req.Method, "url", req.URL.Redacted(), "duration", duration, "error", err)
+		t.recordHAR(req, req, nil, nil, start, duration, err)
+		return nil, err
+	}
+	if t.har != nil || t.logger.Enabled(req.Context(), slog.LevelDebug) {
+		body, err := responseBody(res)
+		if err != nil {
+			return nil, err
+		}
+		// 署名されたヘッダを記録するため、実際に送信したリクエストがあればそちらを使う
+		sent := req
+		if res.Request != nil {
+			sent = res.Request
+		}
+		t.logHTTPRequest(req.Context(), req, sent, res, body, duration)
+		t.recordHAR(req, sent, res, body, start, duration, nil)
+	}
+	t.recordClockSkew(res)
+
+	if corrected := clockOffset(t.authenticator); corrected != offset {
+		t.logger.WarnContext(req.Context(), "the local clock differs from the server's clock; timestamps for authentication are corrected. Synchronize the clock, e.g. with NTP",
+			"offset", corrected)
+	}
+	if index := apiKeyIndex(t.authenticator); index != keyIndex {
+		t.logger.WarnContext(req.Context(), "the API key was rejected and a fallback API key is used. Replace the stale API key",
+			"stale_index", keyIndex, "index", index)
+	}
+
+	return res, nil
+}
 
-	return t.Transport.RoundTrip(req)
+// recordHAR はリクエストとレスポンスを HAR ファイルに追記する
+// 書き込めなくてもリクエストは失敗させず、警告をログに出力する
+func (t *transport) recordHAR(req, sent *http.Request, res *http.Response, body []byte, start time.Time, duration time.Duration, roundTripErr error) {
+	if t.har == nil {
+		return
+	}
+	entry := newHAREntry(req, sent, requestBody(req), res, body, start, duration, roundTripErr)
+	creator := harCreator{Name: "terraform-provider-hatenablog-members", Version: t.version}
+	if err := t.har.append(entry, creator); err != nil {
+		t.logger.WarnContext(req.Context(), "unable to write the exchange with the Hatena Blog API to the HAR file",
+			"path", t.har.path, "error", err)
+	}
+}
+
+// clockOffset は Authenticator が時計のずれとして補正している値を返す
+func clockOffset(authenticator Authenticator) time.Duration {
+	if a, ok := authenticator.(interface{ ClockOffset() time.Duration }); ok {
+		return a.ClockOffset()
+	}
+	return 0
+}
+
+// recordClockSkew はレスポンスの Date ヘッダからサーバーとの時計のずれを記録する
+func (t *transport) recordClockSkew(res *http.Response) {
+	date, err := http.ParseTime(res.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+	t.clockSkew.Store(int64(date.Sub(time.Now())))
+	t.hasClockSkew.Store(true)
+}
+
+// apiKeyIndex は Authenticator が使用しているAPIキーの番号を返す
+func apiKeyIndex(authenticator Authenticator) int {
+	if a, ok := authenticator.(interface{ APIKeyIndex() int }); ok {
+		return a.APIKeyIndex()
+	}
+	return 0
 }
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// clockSkewTolerance は補正せずに許容するサーバーとの時計のずれ
+const clockSkewTolerance = time.Minute
+
+// wsseCreatedFormat は X-WSSE ヘッダの Created の形式
+const wsseCreatedFormat = "2006-01-02T15:04:05Z"
+
+// wsseTransport は X-WSSE ヘッダを付与してリクエストを送信する
+// サーバーの時計とのずれが大きいときは Created を補正し、認証に失敗していれば一度だけやり直す
+// それでも認証に失敗したときは予備のAPIキーでやり直す
+type wsseTransport struct {
+	authenticator *WSSEAuthenticator
+	base          http.RoundTripper
+}
+
+func (t *wsseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	index := t.authenticator.APIKeyIndex()
+	res, err := t.roundTrip(req, index)
+	if err != nil {
+		return nil, err
+	}
+
+	if skew, ok := serverClockSkew(res, t.authenticator.now()); ok && t.authenticator.correctClockOffset(skew) && res.StatusCode == http.StatusUnauthorized {
+		res, err = t.retry(req, res, index)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 認証に失敗したときは予備のAPIキーを順に試し、成功したAPIキーを以降のリクエストで使う
+	for i := index + 1; res.StatusCode == http.StatusUnauthorized && i <= len(t.authenticator.FallbackAPIKeys); i++ {
+		res, err = t.retry(req, res, i)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusUnauthorized {
+			t.authenticator.keyIndex.Store(int32(i))
+		}
+	}
+
+	return res, nil
+}
+
+// retry は失敗したレスポンスを破棄して、index 番目のAPIキーでリクエストをやり直す
+// 本文を読み直せないリクエストはやり直せないので、失敗したレスポンスをそのまま返す
+func (t *wsseTransport) retry(req *http.Request, res *http.Response, index int) (*http.Response, error) {
+	if req.Body != nil && req.GetBody == nil {
+		return res, nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return res, nil
+		}
+		retry.Body = body
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	return t.roundTrip(retry, index)
+}
+
+func (t *wsseTransport) roundTrip(req *http.Request, index int) (*http.Response, error) {
+	header, err := t.authenticator.header(index)
+	if err != nil {
+		return nil, err
+	}
+
+	// RoundTripper はリクエストを変更してはいけないので複製してからヘッダを付与する
+	signed := req.Clone(req.Context())
+	signed.Header.Set("X-WSSE", header)
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
+
+// header は index 番目のAPIキーによる X-WSSE ヘッダの値を返す
+// Created はサーバーの時計とのずれを補正した時刻になる
+func (a *WSSEAuthenticator) header(index int) (string, error) {
+	newNonce := randomBytes
+	if a.nonceFunc != nil {
+		newNonce = a.nonceFunc
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return "", err
+	}
+	created := a.now().Add(a.ClockOffset()).UTC().Format(wsseCreatedFormat)
+
+	return fmt.Sprintf(
+		`UsernameToken Username="%s", PasswordDigest="%s", Nonce="%s", Created="%s"`,
+		a.Username,
+		base64.StdEncoding.EncodeToString(wssePasswordDigest(nonce, created, a.apiKey(index))),
+		base64.StdEncoding.EncodeToString(nonce),
+		created,
+	), nil
+}
+
+// correctClockOffset はサーバーとの時計のずれが補正の範囲を超えていれば補正し、補正したかどうかを返す
+func (a *WSSEAuthenticator) correctClockOffset(skew time.Duration) bool {
+	diff := skew - a.ClockOffset()
+	if -clockSkewTolerance <= diff && diff <= clockSkewTolerance {
+		return false
+	}
+	a.offset.Store(int64(skew))
+	return true
+}
+
+func (a *WSSEAuthenticator) now() time.Time {
+	if a.nowFunc != nil {
+		return a.nowFunc()
+	}
+	return time.Now()
+}
+
+// wssePasswordDigest は Base64(SHA1(Nonce + Created + Password)) のうち Base64 の前の値を返す
+func wssePasswordDigest(nonce []byte, created, password string) []byte {
+	digest := sha1.New()
+	digest.Write(nonce)
+	digest.Write([]byte(created))
+	digest.Write([]byte(password))
+	return digest.Sum(nil)
+}
+
+// serverClockSkew はレスポンスの Date ヘッダから、now に対するサーバーの時計の進みを返す
+func serverClockSkew(res *http.Response, now time.Time) (time.Duration, bool) {
+	date, err := http.ParseTime(res.Header.Get("Date"))
+	if err != nil {
+		return 0, false
+	}
+	return date.Sub(now), true
+}
+
+func randomBytes() ([]byte, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
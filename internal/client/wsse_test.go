@@ -0,0 +1,173 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWSSEAuthenticator_header(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	a := &WSSEAuthenticator{
+		Username:  "username",
+		APIKey:    "apikey",
+		nowFunc:   func() time.Time { return time.Date(2024, 1, 2, 12, 4, 5, 0, jst) },
+		nonceFunc: func() ([]byte, error) { return []byte("0123456789abcdef"), nil },
+	}
+
+	header, err := a.header(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Created はUTCで表す
+	created := "2024-01-02T03:04:05Z"
+	digest := sha1.Sum([]byte("0123456789abcdef" + created + "apikey"))
+	want := fmt.Sprintf(`UsernameToken Username="username", PasswordDigest="%s", Nonce="%s", Created="%s"`,
+		base64.StdEncoding.EncodeToString(digest[:]), base64.StdEncoding.EncodeToString([]byte("0123456789abcdef")), created)
+	if header != want {
+		t.Errorf("unexpected header:\n got: %s\nwant: %s", header, want)
+	}
+}
+
+func TestWSSEAuthenticator_randomNonce(t *testing.T) {
+	a := &WSSEAuthenticator{Username: "username", APIKey: "apikey"}
+
+	nonces := map[string]bool{}
+	for range 10 {
+		header, err := a.header(0)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		m := wssePattern.FindStringSubmatch(header)
+		if m == nil {
+			t.Fatalf("unexpected header: %s", header)
+		}
+		if nonces[m[3]] {
+			t.Errorf("nonce is reused: %s", m[3])
+		}
+		nonces[m[3]] = true
+	}
+}
+
+// skewedServer はローカルの時計より skew だけ進んだ時計を持ち、
+// Created がサーバーの時計から5分以上ずれていると認証に失敗するサーバー
+type skewedServer struct {
+	now      time.Time
+	skew     time.Duration
+	requests []string
+}
+
+func (s *skewedServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	serverNow := s.now.Add(s.skew)
+	m := wssePattern.FindStringSubmatch(req.Header.Get("X-WSSE"))
+	if m == nil {
+		return nil, fmt.Errorf("invalid X-WSSE header: %q", req.Header.Get("X-WSSE"))
+	}
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+	s.requests = append(s.requests, fmt.Sprintf("%s %s", m[4], body))
+
+	status := http.StatusOK
+	created, err := time.Parse(wsseCreatedFormat, m[4])
+	if err != nil || created.Sub(serverNow).Abs() > 5*time.Minute {
+		status = http.StatusUnauthorized
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Date": []string{serverNow.UTC().Format(http.TimeFormat)}},
+		Body:       io.NopCloser(strings.NewReader(`{"members":[]}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestWSSEAuthenticator_clockSkew(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		skew         time.Duration
+		wantOffset   time.Duration
+		wantRequests []string
+	}{
+		{
+			name:         "small skew",
+			skew:         30 * time.Second,
+			wantOffset:   0,
+			wantRequests: []string{"2024-01-02T03:04:05Z {}", "2024-01-02T03:04:05Z {}"},
+		},
+		{
+			// 認証に失敗したリクエストを補正した時刻でやり直し、以降は補正した時刻を使う
+			name:         "server ahead",
+			skew:         10 * time.Minute,
+			wantOffset:   10 * time.Minute,
+			wantRequests: []string{"2024-01-02T03:04:05Z {}", "2024-01-02T03:14:05Z {}", "2024-01-02T03:14:05Z {}"},
+		},
+		{
+			name:         "server behind",
+			skew:         -time.Hour,
+			wantOffset:   -time.Hour,
+			wantRequests: []string{"2024-01-02T03:04:05Z {}", "2024-01-02T02:04:05Z {}", "2024-01-02T02:04:05Z {}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &skewedServer{now: now, skew: tt.skew}
+			a := &WSSEAuthenticator{
+				Username:  "username",
+				APIKey:    "apikey",
+				nowFunc:   func() time.Time { return now },
+				nonceFunc: func() ([]byte, error) { return []byte("nonce"), nil },
+			}
+			rt := a.Transport(server)
+
+			for range 2 {
+				req, _ := http.NewRequest("POST", "https://blog.hatena.ne.jp/owner/blog.example.com/api/members", bytes.NewBufferString("{}"))
+				res, err := rt.RoundTrip(req)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if res.StatusCode != http.StatusOK {
+					t.Errorf("unexpected status: %d", res.StatusCode)
+				}
+			}
+
+			if a.ClockOffset() != tt.wantOffset {
+				t.Errorf("unexpected offset: %s", a.ClockOffset())
+			}
+			if strings.Join(server.requests, "\n") != strings.Join(tt.wantRequests, "\n") {
+				t.Errorf("unexpected requests: %q", server.requests)
+			}
+		})
+	}
+}
+
+func TestClient_ClockOffset(t *testing.T) {
+	server := &skewedServer{now: time.Now(), skew: 10 * time.Minute}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	client := NewClient("test", "username", "apikey", "owner", "blog.example.com", WithTransport(server), WithLogger(logger))
+
+	if _, err := client.ListMembers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	offset := client.ClockOffset()
+	if offset < 9*time.Minute || 11*time.Minute < offset {
+		t.Errorf("unexpected offset: %s", offset)
+	}
+	if !strings.Contains(buf.String(), "level=WARN") {
+		t.Errorf("the correction should be warned: %s", buf.String())
+	}
+}
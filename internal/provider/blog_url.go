@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// subdirectoryDomain はサブディレクトリオプション（はてなブログMedia）のブログに割り当てられるドメイン
+const subdirectoryDomain = ".hatenablog-oem.com"
+
+// parseBlogURL はブログのURLからAPIで使うブログのホストを取り出す
+// サブディレクトリオプションのブログでは https://0123456789.hatenablog-oem.com の 0123456789 がホストになる
+func parseBlogURL(blogURL string) (blogHost string, subdirectory bool, err error) {
+	u, err := url.Parse(blogURL)
+	if err != nil {
+		return "", false, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false, fmt.Errorf("blog URL must start with 'https://' or 'http://', got: %s", blogURL)
+	}
+	if u.Hostname() == "" {
+		return "", false, fmt.Errorf("blog URL must have a host, got: %s", blogURL)
+	}
+	if u.User != nil || u.Port() != "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", false, fmt.Errorf("blog URL must not have user info, port, query or fragment, got: %s", blogURL)
+	}
+	// 記事のURLなどを渡されたときに、サブディレクトリのブログと取り違えないようにパスは受け付けない
+	if u.Path != "" && u.Path != "/" {
+		return "", false, fmt.Errorf("blog URL must be the top page of the blog without a path, got: %s. For a blog using the subdirectory option, use its URL like 'https://0123456789%s'", blogURL, subdirectoryDomain)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if id, ok := strings.CutSuffix(host, subdirectoryDomain); ok {
+		if id == "" || strings.Contains(id, ".") {
+			return "", false, fmt.Errorf("unexpected blog URL for the subdirectory option, got: %s", blogURL)
+		}
+		return id, true, nil
+	}
+	return host, false, nil
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestParseBlogURL(t *testing.T) {
+	tests := []struct {
+		blogURL          string
+		wantBlogHost     string
+		wantSubdirectory bool
+		wantErr          bool
+	}{
+		{blogURL: "https://staff.hatenablog.com/", wantBlogHost: "staff.hatenablog.com"},
+		{blogURL: "https://staff.hatenablog.com", wantBlogHost: "staff.hatenablog.com"},
+		{blogURL: "http://Staff.Hatenablog.com/", wantBlogHost: "staff.hatenablog.com"},
+		{blogURL: "https://blog.example.com/", wantBlogHost: "blog.example.com"},
+		{blogURL: "https://0123456789.hatenablog-oem.com", wantBlogHost: "0123456789", wantSubdirectory: true},
+		{blogURL: "https://0123456789.hatenablog-oem.com/", wantBlogHost: "0123456789", wantSubdirectory: true},
+		{blogURL: "staff.hatenablog.com", wantErr: true},
+		{blogURL: "ftp://staff.hatenablog.com/", wantErr: true},
+		{blogURL: "https://staff.hatenablog.com/entry/2024/01/01/000000", wantErr: true},
+		{blogURL: "https://example.com/blog/", wantErr: true},
+		{blogURL: "https://staff.hatenablog.com/?page=2", wantErr: true},
+		{blogURL: "https://staff.hatenablog.com:8080/", wantErr: true},
+		{blogURL: "https://a.b.hatenablog-oem.com/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.blogURL, func(t *testing.T) {
+			blogHost, subdirectory, err := parseBlogURL(tt.blogURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if blogHost != tt.wantBlogHost || subdirectory != tt.wantSubdirectory {
+				t.Errorf("unexpected result: %q, %v", blogHost, subdirectory)
+			}
+		})
+	}
+}
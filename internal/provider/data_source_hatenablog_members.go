@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+type BlogMembersDataSource struct {
+	client *client.Client
+
+	warnings *providerWarnings
+}
+
+type membersDataSourceModel struct {
+	Owner    types.String                   `tfsdk:"owner"`
+	BlogHost types.String                   `tfsdk:"blog_host"`
+	Members  []membersDataSourceMemberModel `tfsdk:"members"`
+}
+
+type membersDataSourceMemberModel struct {
+	Username types.String `tfsdk:"username"`
+	Role     types.String `tfsdk:"role"`
+	ImportID types.String `tfsdk:"import_id"`
+}
+
+// ensure that BlogMembersDataSource satisfies interfaces
+var (
+	_ datasource.DataSource              = &BlogMembersDataSource{}
+	_ datasource.DataSourceWithConfigure = &BlogMembersDataSource{}
+)
+
+func NewBlogMembersDataSource() datasource.DataSource {
+	return &BlogMembersDataSource{}
+}
+
+func (d *BlogMembersDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_members"
+}
+
+func (d *BlogMembersDataSource) Schema(_ context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the current members of the blog. Combined with `import` blocks using `for_each`, it allows importing all the existing members at once.",
+		Attributes: map[string]schema.Attribute{
+			"owner": schema.StringAttribute{
+				Description: "The Hatena ID of the owner of the blog.",
+				Computed:    true,
+			},
+			"blog_host": schema.StringAttribute{
+				Description: "The host of the blog.",
+				Computed:    true,
+			},
+			"members": schema.ListNestedAttribute{
+				Description: "The members of the blog.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"username": schema.StringAttribute{
+							Description: "The Hatena ID of the blog member.",
+							Computed:    true,
+						},
+						"role": schema.StringAttribute{
+							Description: "Role of the blog member.",
+							Computed:    true,
+						},
+						"import_id": schema.StringAttribute{
+							Description: "The ID to import the member as a 'hatenablog-members_member' resource, in the form of '<owner>/<blog_host>/<username>'.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *BlogMembersDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data := req.ProviderData.(*blogMemberProviderData)
+	d.client = data.Client
+	d.warnings = data.Warnings
+}
+
+func (d *BlogMembersDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	defer appendStaleAPIKeyWarning(&resp.Diagnostics, d.client, d.warnings)
+	defer appendClockOffsetWarning(&resp.Diagnostics, d.client, d.warnings)
+
+	members, err := d.client.ListMembers(ctx)
+	if err != nil {
+		resp.Diagnostics.Append(apiErrorDiagnostic(ctx, d.client, "Failed to list members", err))
+		return
+	}
+
+	state := membersDataSourceModel{
+		Owner:    types.StringValue(d.client.Owner()),
+		BlogHost: types.StringValue(d.client.BlogHost()),
+		Members:  make([]membersDataSourceMemberModel, 0, len(members)),
+	}
+	for _, member := range members {
+		state.Members = append(state.Members, membersDataSourceMemberModel{
+			Username: types.StringValue(member.Username),
+			Role:     types.StringValue(member.Role),
+			ImportID: types.StringValue(memberID(d.client.Owner(), d.client.BlogHost(), member.Username)),
+		})
+	}
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+// operatorRoleOwner はブログのオーナーであるオペレーターのロール
+const operatorRoleOwner = "owner"
+
+type BlogOperatorDataSource struct {
+	client *client.Client
+
+	warnings *providerWarnings
+}
+
+type operatorDataSourceModel struct {
+	Username         types.String `tfsdk:"username"`
+	Owner            types.String `tfsdk:"owner"`
+	BlogHost         types.String `tfsdk:"blog_host"`
+	Role             types.String `tfsdk:"role"`
+	CanManageMembers types.Bool   `tfsdk:"can_manage_members"`
+	Endpoint         types.String `tfsdk:"endpoint"`
+}
+
+// ensure that BlogOperatorDataSource satisfies interfaces
+var (
+	_ datasource.DataSource              = &BlogOperatorDataSource{}
+	_ datasource.DataSourceWithConfigure = &BlogOperatorDataSource{}
+)
+
+func NewBlogOperatorDataSource() datasource.DataSource {
+	return &BlogOperatorDataSource{}
+}
+
+func (d *BlogOperatorDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_operator"
+}
+
+func (d *BlogOperatorDataSource) Schema(_ context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Describes the operator the provider is running as and the operator's rights on the blog.",
+		Attributes: map[string]schema.Attribute{
+			"username": schema.StringAttribute{
+				Description: "The Hatena ID of the operator.",
+				Computed:    true,
+			},
+			"owner": schema.StringAttribute{
+				Description: "The Hatena ID of the owner of the blog.",
+				Computed:    true,
+			},
+			"blog_host": schema.StringAttribute{
+				Description: "The host of the blog.",
+				Computed:    true,
+			},
+			"role": schema.StringAttribute{
+				Description: "The role of the operator on the blog. One of 'owner', 'admin', 'editor' or 'contributor', or null if the operator is not a member of the blog or is not allowed to list the members.",
+				Computed:    true,
+			},
+			"can_manage_members": schema.BoolAttribute{
+				Description: "Whether the operator can manage the members of the blog, i.e. the operator is the owner or an admin.",
+				Computed:    true,
+			},
+			"endpoint": schema.StringAttribute{
+				Description: "The URL of the API the provider sends requests to.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *BlogOperatorDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data := req.ProviderData.(*blogMemberProviderData)
+	d.client = data.Client
+	d.warnings = data.Warnings
+}
+
+func (d *BlogOperatorDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	defer appendStaleAPIKeyWarning(&resp.Diagnostics, d.client, d.warnings)
+	defer appendClockOffsetWarning(&resp.Diagnostics, d.client, d.warnings)
+
+	state := operatorDataSourceModel{
+		Username: types.StringValue(d.client.Username()),
+		Owner:    types.StringValue(d.client.Owner()),
+		BlogHost: types.StringValue(d.client.BlogHost()),
+		Role:     types.StringNull(),
+		Endpoint: types.StringValue(d.client.Endpoint()),
+	}
+
+	if d.client.Username() == d.client.Owner() {
+		// オーナーはメンバー一覧を見なくても分かる
+		state.Role = types.StringValue(operatorRoleOwner)
+	} else {
+		members, err := d.client.ListMembers(ctx)
+		if errors.Is(err, client.ErrForbidden) {
+			// 管理者でなければメンバー一覧を取得できないので、ロールは分からない
+			members = nil
+		} else if err != nil {
+			resp.Diagnostics.Append(apiErrorDiagnostic(ctx, d.client, "Failed to list members", err))
+			return
+		}
+		for _, member := range members {
+			if member.Username == d.client.Username() {
+				state.Role = types.StringValue(member.Role)
+				break
+			}
+		}
+	}
+	role, _ := findRole(state.Role.ValueString())
+	state.CanManageMembers = types.BoolValue(state.Role.ValueString() == operatorRoleOwner || role.CanManageMembers)
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
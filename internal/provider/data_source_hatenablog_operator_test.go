@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+func TestBlogOperatorDataSource_Read(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/owner/blog.example.com/api/members" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if strings.Contains(r.Header.Get("X-WSSE"), `Username="forbidden"`) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"members":[{"username":"admin","role":"admin"},{"username":"editor","role":"editor"}]}`)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	ctx := context.Background()
+	var schemaResp datasource.SchemaResponse
+	(&BlogOperatorDataSource{}).Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+
+	tests := []struct {
+		username         string
+		wantRole         string
+		wantNullRole     bool
+		canManageMembers bool
+	}{
+		{username: "owner", wantRole: "owner", canManageMembers: true},
+		{username: "admin", wantRole: "admin", canManageMembers: true},
+		{username: "editor", wantRole: "editor"},
+		{username: "stranger", wantNullRole: true},
+		// 管理者でなくメンバー一覧を取得できないとき
+		{username: "forbidden", wantNullRole: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			c := client.NewClient("test", tt.username, "apikey", "owner", "blog.example.com")
+			if err := c.SetEndpoint(server.URL); err != nil {
+				t.Fatal(err)
+			}
+			d := &BlogOperatorDataSource{client: c}
+
+			resp := datasource.ReadResponse{
+				State: tfsdk.State{
+					Schema: schemaResp.Schema,
+					Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+				},
+			}
+			d.Read(ctx, datasource.ReadRequest{}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			var state operatorDataSourceModel
+			resp.State.Get(ctx, &state)
+			if state.Username.ValueString() != tt.username || state.Owner.ValueString() != "owner" || state.BlogHost.ValueString() != "blog.example.com" {
+				t.Errorf("unexpected state: %v", state)
+			}
+			if tt.wantNullRole {
+				if !state.Role.IsNull() {
+					t.Errorf("role should be null: %s", state.Role)
+				}
+			} else if state.Role.ValueString() != tt.wantRole {
+				t.Errorf("unexpected role: %s", state.Role)
+			}
+			if state.CanManageMembers.ValueBool() != tt.canManageMembers {
+				t.Errorf("unexpected can_manage_members: %s", state.CanManageMembers)
+			}
+			if state.Endpoint.ValueString() != fmt.Sprintf("http://%s/owner/blog.example.com/api", serverURL.Host) {
+				t.Errorf("unexpected endpoint: %s", state.Endpoint)
+			}
+		})
+	}
+}
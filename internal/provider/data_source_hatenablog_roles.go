@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type BlogRolesDataSource struct{}
+
+type rolesDataSourceModel struct {
+	Roles []rolesDataSourceRoleModel `tfsdk:"roles"`
+	Ranks map[string]types.Int64     `tfsdk:"ranks"`
+}
+
+type rolesDataSourceRoleModel struct {
+	Name               types.String `tfsdk:"name"`
+	Label              types.String `tfsdk:"label"`
+	Rank               types.Int64  `tfsdk:"rank"`
+	CanPublish         types.Bool   `tfsdk:"can_publish"`
+	CanEditOthersPosts types.Bool   `tfsdk:"can_edit_others_posts"`
+	CanManageSettings  types.Bool   `tfsdk:"can_manage_settings"`
+	CanManageMembers   types.Bool   `tfsdk:"can_manage_members"`
+}
+
+// ensure that BlogRolesDataSource satisfies interfaces
+var (
+	_ datasource.DataSource = &BlogRolesDataSource{}
+)
+
+func NewBlogRolesDataSource() datasource.DataSource {
+	return &BlogRolesDataSource{}
+}
+
+func (d *BlogRolesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_roles"
+}
+
+func (d *BlogRolesDataSource) Schema(_ context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Describes the roles which can be given to the members of the blog and what each role can do.",
+		Attributes: map[string]schema.Attribute{
+			"roles": schema.ListNestedAttribute{
+				Description: "The roles, ordered from the most privileged.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "The name of the role, used as 'role' of the member resource.",
+							Computed:    true,
+						},
+						"label": schema.StringAttribute{
+							Description: "The Japanese label of the role shown on Hatena Blog.",
+							Computed:    true,
+						},
+						"rank": schema.Int64Attribute{
+							Description: "The rank of the role. A role with a higher rank can do more.",
+							Computed:    true,
+						},
+						"can_publish": schema.BoolAttribute{
+							Description: "Whether the role can publish posts.",
+							Computed:    true,
+						},
+						"can_edit_others_posts": schema.BoolAttribute{
+							Description: "Whether the role can edit posts written by others.",
+							Computed:    true,
+						},
+						"can_manage_settings": schema.BoolAttribute{
+							Description: "Whether the role can manage the settings of the blog.",
+							Computed:    true,
+						},
+						"can_manage_members": schema.BoolAttribute{
+							Description: "Whether the role can manage the members of the blog.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"ranks": schema.MapAttribute{
+				Description: "A map from the name of each role to its rank, e.g. to check that a role is at least 'editor'.",
+				ElementType: types.Int64Type,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *BlogRolesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	state := rolesDataSourceModel{
+		Roles: make([]rolesDataSourceRoleModel, 0, len(blogRoles)),
+		Ranks: make(map[string]types.Int64, len(blogRoles)),
+	}
+	for _, role := range blogRoles {
+		state.Roles = append(state.Roles, rolesDataSourceRoleModel{
+			Name:               types.StringValue(role.Name),
+			Label:              types.StringValue(role.Label),
+			Rank:               types.Int64Value(int64(role.Rank)),
+			CanPublish:         types.BoolValue(role.CanPublish),
+			CanEditOthersPosts: types.BoolValue(role.CanEditOthersPosts),
+			CanManageSettings:  types.BoolValue(role.CanManageSettings),
+			CanManageMembers:   types.BoolValue(role.CanManageMembers),
+		})
+		state.Ranks[role.Name] = types.Int64Value(int64(role.Rank))
+	}
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+// apikeyConfigURL はAPIキーを確認できるページのURL
+const apikeyConfigURL = "https://blog.hatena.ne.jp/-/config"
+
+// apiErrorDiagnostic はAPIの呼び出しに失敗したときのエラーを返す
+// 認証や権限の問題など原因が分かるときは、見直すべきプロバイダの設定を英語と日本語で示す
+// 操作がタイムアウトしたときは、どの操作をどれだけ待ったかを示す
+// TLS証明書を検証できないときは、プロキシのCA証明書の指定を促す
+//
+// リソースやデータソースの診断の属性のパスはリソースの設定の中で解決されるので、プロバイダの属性は示せない
+// プロバイダの設定を検証するときは providerAPIErrorDiagnostic を使う
+func apiErrorDiagnostic(ctx context.Context, c *client.Client, message string, err error) diag.Diagnostic {
+	d, _ := describeAPIError(ctx, c, message, err)
+	return d
+}
+
+// providerAPIErrorDiagnostic は apiErrorDiagnostic と同じエラーを、原因と考えられるプロバイダの属性に対して返す
+func providerAPIErrorDiagnostic(ctx context.Context, c *client.Client, message string, err error) diag.Diagnostic {
+	d, p := describeAPIError(ctx, c, message, err)
+	if p.Equal(path.Empty()) {
+		return d
+	}
+	return diag.NewAttributeErrorDiagnostic(p, d.Summary(), d.Detail())
+}
+
+// describeAPIError はAPIのエラーと、その原因と考えられるプロバイダの属性を返す
+// 原因となる属性が分からないときは空のパスを返す
+func describeAPIError(ctx context.Context, c *client.Client, message string, err error) (diag.Diagnostic, path.Path) {
+	// http.Client のタイムアウトも context.DeadlineExceeded として扱われるので、操作のタイムアウトより先に判定する
+	var netErr net.Error
+	if c.RequestTimeout() > 0 && ctx.Err() == nil && errors.As(err, &netErr) && netErr.Timeout() {
+		return diag.NewErrorDiagnostic(
+			"Request timed out",
+			fmt.Sprintf("%s: the API did not respond within %s. If the API or the proxy is slow, increase 'request_timeout' in the provider configuration.\n\n%s", message, c.RequestTimeout(), err),
+		), path.Root("request_timeout")
+	}
+	if op, ok := ctx.Value(operationTimeoutKey{}).(operationTimeout); ok && errors.Is(err, context.DeadlineExceeded) {
+		return diag.NewErrorDiagnostic(
+			"Operation timed out",
+			fmt.Sprintf("%s: the %s operation did not complete within %s. If the API is slow, increase timeouts.%s of the resource.", message, op.operation, op.timeout, op.operation),
+		), path.Empty()
+	}
+
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return diag.NewErrorDiagnostic(
+			"Untrusted TLS certificate",
+			fmt.Sprintf("%s: the TLS certificate of the server could not be verified. If the requests go through an intercepting proxy, add its CA certificate with 'ca_cert_file' or 'ca_cert_pem' in the provider configuration.\n\n"+
+				"サーバーのTLS証明書を検証できませんでした。通信を中継するプロキシを経由しているときは、プロバイダ設定の 'ca_cert_file' か 'ca_cert_pem' にプロキシのCA証明書を指定してください。\n\n%s",
+				message, err),
+		), path.Empty()
+	}
+
+	blog := fmt.Sprintf("%s/%s", c.Owner(), c.BlogHost())
+
+	_, oauth := c.Authenticator().(*client.OAuth1Authenticator)
+
+	switch {
+	case oauth && (errors.Is(err, client.ErrUnauthorized) || errors.Is(err, client.ErrLoginRedirect)):
+		return diag.NewErrorDiagnostic(
+			"Invalid OAuth credentials",
+			fmt.Sprintf("%s: the API rejected the OAuth credentials. Check 'oauth_consumer_key', 'oauth_consumer_secret', 'oauth_token' and 'oauth_token_secret' in the provider configuration. The access token may have been revoked.\n\n"+
+				"OAuth の認証に失敗しました。プロバイダ設定の 'oauth_consumer_key'、'oauth_consumer_secret'、'oauth_token'、'oauth_token_secret' を確認してください。アクセストークンが無効化されている可能性があります。\n\n%s",
+				message, err),
+		), path.Root("oauth_token")
+	case errors.Is(err, client.ErrUnauthorized):
+		return diag.NewErrorDiagnostic(
+			"Invalid API key",
+			fmt.Sprintf("%s: the API rejected the credentials of %q. Check 'username' and 'apikey' in the provider configuration. The API key can be found at %s.\n\n"+
+				"%q の認証に失敗しました。プロバイダ設定の 'username' と 'apikey' を確認してください。APIキーは %s で確認できます。\n\n%s",
+				message, c.Username(), apikeyConfigURL, c.Username(), apikeyConfigURL, err),
+		), path.Root("apikey")
+	case errors.Is(err, client.ErrLoginRedirect):
+		return diag.NewErrorDiagnostic(
+			"Redirected to the login page",
+			fmt.Sprintf("%s: the request was redirected to the login page because it was not authenticated. Check 'username' and 'apikey' in the provider configuration. The API key can be found at %s.\n\n"+
+				"認証されずにログインページにリダイレクトされました。プロバイダ設定の 'username' と 'apikey' を確認してください。APIキーは %s で確認できます。\n\n%s",
+				message, apikeyConfigURL, apikeyConfigURL, err),
+		), path.Root("apikey")
+	case errors.Is(err, client.ErrForbidden):
+		return diag.NewErrorDiagnostic(
+			"Operator lacks admin privileges",
+			fmt.Sprintf("%s: %q does not have administrative privileges on the blog %s. 'username' in the provider configuration must be the owner or an admin of the blog.\n\n"+
+				"%q はブログ %s の管理権限を持っていません。プロバイダ設定の 'username' にはブログのオーナーか管理者のはてなIDを指定してください。\n\n%s",
+				message, c.Username(), blog, c.Username(), blog, err),
+		), path.Root("username")
+	case errors.Is(err, client.ErrBlogNotFound) && c.Owner() != c.Username():
+		// owner を明示しているときは、owner がブログのオーナーと一致していない可能性が高い
+		return diag.NewErrorDiagnostic(
+			"Blog not found for the owner",
+			fmt.Sprintf("%s: the blog %q owned by %q was not found. Check that 'owner' in the provider configuration is the Hatena ID of the owner of the blog, and that 'blog_host' is correct.\n\n"+
+				"%q がオーナーのブログ %q が見つかりません。プロバイダ設定の 'owner' がブログのオーナーのはてなIDであること、'blog_host' が正しいことを確認してください。\n\n%s",
+				message, c.BlogHost(), c.Owner(), c.Owner(), c.BlogHost(), err),
+		), path.Root("owner")
+	case errors.Is(err, client.ErrBlogNotFound):
+		return diag.NewErrorDiagnostic(
+			"Blog not found",
+			fmt.Sprintf("%s: the blog %q was not found. Check 'blog_host' in the provider configuration. If %q is not the owner of the blog, set 'owner' to the Hatena ID of the owner.\n\n"+
+				"ブログ %q が見つかりません。プロバイダ設定の 'blog_host' を確認してください。%q がブログのオーナーでないときは、'owner' にオーナーのはてなIDを指定してください。\n\n%s",
+				message, c.BlogHost(), c.Username(), c.BlogHost(), c.Username(), err),
+		), path.Root("blog_host")
+	}
+
+	return diag.NewErrorDiagnostic("API Error", fmt.Sprintf("%s: %s", message, err.Error())), path.Empty()
+}
+
+// providerWarnings はプロバイダの設定ごとに一度だけ出す警告を、出したかどうか
+// プロバイダの設定ごとに作り、リソースとデータソースで共有する
+type providerWarnings struct {
+	staleAPIKey sync.Once
+	clockOffset sync.Once
+}
+
+// appendStaleAPIKeyWarning は apikey が拒否されて予備のAPIキーに切り替えたことを、プロバイダの設定ごとに一度だけ警告する
+// 操作の途中で切り替わることもあるので defer で呼び出す
+// w が nil のときは呼び出すたびに警告する
+func appendStaleAPIKeyWarning(diags *diag.Diagnostics, c *client.Client, w *providerWarnings) {
+	if c == nil {
+		return
+	}
+	index := c.APIKeyIndex()
+	if index == 0 {
+		return
+	}
+	if w == nil {
+		w = &providerWarnings{}
+	}
+	w.staleAPIKey.Do(func() {
+		stale := []string{"'apikey'"}
+		for i := 0; i < index-1; i++ {
+			stale = append(stale, fmt.Sprintf("'apikey_fallbacks[%d]'", i))
+		}
+		current := fmt.Sprintf("'apikey_fallbacks[%d]'", index-1)
+		diags.AddWarning(
+			"Stale API key",
+			fmt.Sprintf("The API rejected %s, so %s is used instead. Set 'apikey' in the provider configuration to the current API key, which can be found at %s, and remove the stale ones from 'apikey_fallbacks'.\n\n"+
+				"%s が拒否されたため、%s を使用しました。プロバイダ設定の 'apikey' を %s で確認できる現在のAPIキーに更新し、古いAPIキーを 'apikey_fallbacks' から削除してください。",
+				strings.Join(stale, ", "), current, apikeyConfigURL, strings.Join(stale, "、"), current, apikeyConfigURL),
+		)
+	})
+}
+
+// appendClockOffsetWarning はローカルの時計のずれを補正してWSSE認証したことを、プロバイダの設定ごとに一度だけ警告する
+// 最初のレスポンスで補正されるので defer で呼び出す
+// w が nil のときは呼び出すたびに警告する
+func appendClockOffsetWarning(diags *diag.Diagnostics, c *client.Client, w *providerWarnings) {
+	if c == nil {
+		return
+	}
+	offset := c.ClockOffset()
+	if offset == 0 {
+		return
+	}
+	if w == nil {
+		w = &providerWarnings{}
+	}
+	w.clockOffset.Do(func() {
+		diags.AddWarning(
+			"Clock skew corrected",
+			fmt.Sprintf("The local clock differs from the server's clock by %s. The timestamps for WSSE authentication are corrected by the difference, but synchronize the clock of the machine running Terraform, e.g. with NTP.\n\n"+
+				"ローカルの時計がサーバーの時計と %s ずれているため、WSSE認証の時刻を補正しました。NTPなどでTerraformを実行するマシンの時計を合わせてください。",
+				offset.Round(time.Second), offset.Round(time.Second)),
+		)
+	})
+}
@@ -0,0 +1,267 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+func TestAPIErrorDiagnostic(t *testing.T) {
+	c := client.NewClient("test", "username", "apikey", "username", "blog.example.com")
+	otherOwner := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+	oauth := client.NewClient("test", "username", "", "username", "blog.example.com", client.WithAuthenticator(&client.OAuth1Authenticator{}))
+	requestTimeout := client.NewClient("test", "username", "apikey", "username", "blog.example.com")
+	requestTimeout.SetRequestTimeout(10 * time.Second)
+	timeoutCtx, cancel := withOperationTimeout(context.Background(), "create", 30*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		client      *client.Client
+		err         error
+		wantSummary string
+		wantDetail  []string
+		// wantPath はプロバイダの設定を検証するときに示す属性
+		wantPath path.Path
+	}{
+		{
+			name:        "timeout",
+			ctx:         timeoutCtx,
+			client:      c,
+			err:         fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			wantSummary: "Operation timed out",
+			wantDetail:  []string{"the create operation did not complete within 30s"},
+		},
+		{
+			// タイムアウトが設定されていない操作ではタイムアウトとして扱わない
+			name:        "deadline without timeout",
+			ctx:         context.Background(),
+			client:      c,
+			err:         context.DeadlineExceeded,
+			wantSummary: "API Error",
+			wantDetail:  []string{"Failed to list members: context deadline exceeded"},
+		},
+		{
+			name:        "request timeout",
+			ctx:         timeoutCtx,
+			client:      requestTimeout,
+			err:         &url.Error{Op: "Get", URL: "https://blog.hatena.ne.jp/", Err: timeoutError{}},
+			wantSummary: "Request timed out",
+			wantDetail:  []string{"within 10s", "'request_timeout'"},
+			wantPath:    path.Root("request_timeout"),
+		},
+		{
+			name:        "untrusted certificate",
+			ctx:         context.Background(),
+			client:      c,
+			err:         &url.Error{Op: "Get", URL: "https://blog.hatena.ne.jp/", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}},
+			wantSummary: "Untrusted TLS certificate",
+			wantDetail:  []string{"'ca_cert_file'", "'ca_cert_pem'", "CA証明書"},
+		},
+		{
+			name:        "unauthorized",
+			ctx:         context.Background(),
+			client:      c,
+			err:         &client.APIError{StatusCode: 401},
+			wantSummary: "Invalid API key",
+			wantDetail:  []string{"'apikey'", apikeyConfigURL, "認証に失敗しました"},
+			wantPath:    path.Root("apikey"),
+		},
+		{
+			name:        "oauth unauthorized",
+			ctx:         context.Background(),
+			client:      oauth,
+			err:         &client.APIError{StatusCode: 401},
+			wantSummary: "Invalid OAuth credentials",
+			wantDetail:  []string{"'oauth_token'", "OAuth の認証に失敗しました"},
+			wantPath:    path.Root("oauth_token"),
+		},
+		{
+			name:        "login redirect",
+			ctx:         context.Background(),
+			client:      c,
+			err:         &client.APIError{StatusCode: 302, Location: "https://www.hatena.ne.jp/login"},
+			wantSummary: "Redirected to the login page",
+			wantDetail:  []string{"'apikey'", apikeyConfigURL, "ログインページ"},
+			wantPath:    path.Root("apikey"),
+		},
+		{
+			name:        "forbidden",
+			ctx:         context.Background(),
+			client:      c,
+			err:         &client.APIError{StatusCode: 403},
+			wantSummary: "Operator lacks admin privileges",
+			wantDetail:  []string{"'username'", "username/blog.example.com", "管理権限"},
+			wantPath:    path.Root("username"),
+		},
+		{
+			name:        "blog not found",
+			ctx:         context.Background(),
+			client:      c,
+			err:         &client.APIError{StatusCode: 404, URL: "https://blog.hatena.ne.jp/username/blog.example.com/api/members"},
+			wantSummary: "Blog not found",
+			wantDetail:  []string{"'blog_host'", "ブログ \"blog.example.com\" が見つかりません"},
+			wantPath:    path.Root("blog_host"),
+		},
+		{
+			name:        "owner mismatch",
+			ctx:         context.Background(),
+			client:      otherOwner,
+			err:         &client.APIError{StatusCode: 404, URL: "https://blog.hatena.ne.jp/owner/blog.example.com/api/members"},
+			wantSummary: "Blog not found for the owner",
+			wantDetail:  []string{"'owner'", "オーナーのはてなID"},
+			wantPath:    path.Root("owner"),
+		},
+		{
+			name:        "other",
+			ctx:         context.Background(),
+			client:      c,
+			err:         &client.APIError{StatusCode: 500, Body: "error"},
+			wantSummary: "API Error",
+			wantDetail:  []string{"Failed to list members: unexpected status code: 500, body: error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := apiErrorDiagnostic(tt.ctx, tt.client, "Failed to list members", tt.err)
+			if d.Summary() != tt.wantSummary {
+				t.Errorf("unexpected summary: %s", d.Summary())
+			}
+			for _, want := range tt.wantDetail {
+				if !strings.Contains(d.Detail(), want) {
+					t.Errorf("detail should contain %q: %s", want, d.Detail())
+				}
+			}
+			// リソースの設定の属性と取り違えないように、プロバイダの属性は示さない
+			if _, ok := d.(diag.DiagnosticWithPath); ok {
+				t.Errorf("diagnostic should not have a path: %v", d)
+			}
+
+			pd := providerAPIErrorDiagnostic(tt.ctx, tt.client, "Failed to list members", tt.err)
+			var gotPath path.Path
+			if withPath, ok := pd.(diag.DiagnosticWithPath); ok {
+				gotPath = withPath.Path()
+			}
+			if !gotPath.Equal(tt.wantPath) || pd.Summary() != tt.wantSummary {
+				t.Errorf("unexpected diagnostic for the provider: %s at %s", pd.Summary(), gotPath)
+			}
+		})
+	}
+}
+
+// timeoutError は http.Client のタイムアウトによるエラーを模す
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "Client.Timeout exceeded while awaiting headers" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestAppendStaleAPIKeyWarning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptedKey(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"members":[]}`)
+	}))
+	defer server.Close()
+
+	authenticator := &client.WSSEAuthenticator{Username: "username", APIKey: "stale", FallbackAPIKeys: []string{"current"}}
+	c := client.NewClient("test", "username", "", "owner", "blog.example.com", client.WithAuthenticator(authenticator))
+	if err := c.SetEndpoint(server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := &providerWarnings{}
+
+	var diags diag.Diagnostics
+	appendStaleAPIKeyWarning(&diags, c, warnings)
+	if len(diags) != 0 {
+		t.Errorf("unexpected diagnostics before any request: %v", diags)
+	}
+
+	if _, err := c.ListMembers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	appendStaleAPIKeyWarning(&diags, c, warnings)
+	appendStaleAPIKeyWarning(&diags, c, warnings)
+
+	if diags.WarningsCount() != 1 {
+		t.Fatalf("expected a single warning: %v", diags)
+	}
+	d := diags.Warnings()[0]
+	if d.Summary() != "Stale API key" || !strings.Contains(d.Detail(), "The API rejected 'apikey', so 'apikey_fallbacks[0]' is used instead") {
+		t.Errorf("unexpected warning: %s: %s", d.Summary(), d.Detail())
+	}
+
+	// 警告したかどうかはプロバイダの設定ごとに持つ
+	var other diag.Diagnostics
+	appendStaleAPIKeyWarning(&other, c, &providerWarnings{})
+	if other.WarningsCount() != 1 {
+		t.Errorf("expected a warning for another provider configuration: %v", other)
+	}
+}
+
+func TestAppendClockOffsetWarning(t *testing.T) {
+	serverNow := time.Now().Add(-10 * time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", serverNow.UTC().Format(http.TimeFormat))
+		m := regexp.MustCompile(`Created="([^"]+)"`).FindStringSubmatch(r.Header.Get("X-WSSE"))
+		if created, err := time.Parse(time.RFC3339, m[1]); err != nil || created.Sub(serverNow).Abs() > maxClockSkew {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"members":[]}`)
+	}))
+	defer server.Close()
+
+	c := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+	if err := c.SetEndpoint(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	warnings := &providerWarnings{}
+
+	var diags diag.Diagnostics
+	appendClockOffsetWarning(&diags, c, warnings)
+	if len(diags) != 0 {
+		t.Errorf("unexpected diagnostics before any request: %v", diags)
+	}
+
+	// validate_credentials を指定しなくても、リソースやデータソースの操作で一度だけ警告する
+	if _, err := c.ListMembers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	appendClockOffsetWarning(&diags, c, warnings)
+	appendClockOffsetWarning(&diags, c, warnings)
+
+	if diags.WarningsCount() != 1 || diags.Warnings()[0].Summary() != "Clock skew corrected" {
+		t.Errorf("expected a single clock skew warning: %v", diags)
+	}
+}
+
+// acceptedKey は X-WSSE ヘッダが "current" というAPIキーで作られているかを返す
+func acceptedKey(r *http.Request) bool {
+	m := regexp.MustCompile(`PasswordDigest="([^"]+)", Nonce="([^"]+)", Created="([^"]+)"`).FindStringSubmatch(r.Header.Get("X-WSSE"))
+	if m == nil {
+		return false
+	}
+	nonce, _ := base64.StdEncoding.DecodeString(m[2])
+	digest := sha1.Sum([]byte(string(nonce) + m[3] + "current"))
+	return m[1] == base64.StdEncoding.EncodeToString(digest[:])
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type ParseBlogURLFunction struct{}
+
+type parseBlogURLFunctionModel struct {
+	BlogHost     types.String `tfsdk:"blog_host"`
+	Subdirectory types.Bool   `tfsdk:"subdirectory"`
+}
+
+var parseBlogURLFunctionAttributeTypes = map[string]attr.Type{
+	"blog_host":    types.StringType,
+	"subdirectory": types.BoolType,
+}
+
+// ensure that ParseBlogURLFunction satisfies interfaces
+var (
+	_ function.Function = &ParseBlogURLFunction{}
+)
+
+func NewParseBlogURLFunction() function.Function {
+	return &ParseBlogURLFunction{}
+}
+
+func (f *ParseBlogURLFunction) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_blog_url"
+}
+
+func (f *ParseBlogURLFunction) Definition(_ context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Parses the URL of a Hatena blog.",
+		Description: "Parses the URL of the top page of a Hatena blog, e.g. 'https://staff.hatenablog.com/', a custom domain or 'https://0123456789.hatenablog-oem.com' for a blog using the subdirectory option, into the 'blog_host' of the provider. The owner cannot be determined from the URL.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "blog_url",
+				Description: "The URL of the top page of the blog.",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: parseBlogURLFunctionAttributeTypes,
+		},
+	}
+}
+
+func (f *ParseBlogURLFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var blogURL string
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &blogURL))
+	if resp.Error != nil {
+		return
+	}
+
+	blogHost, subdirectory, err := parseBlogURL(blogURL)
+	if err != nil {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewArgumentFuncError(0, err.Error()))
+		return
+	}
+
+	result := parseBlogURLFunctionModel{
+		BlogHost:     types.StringValue(blogHost),
+		Subdirectory: types.BoolValue(subdirectory),
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, &result))
+}
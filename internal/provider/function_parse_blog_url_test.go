@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestParseBlogURLFunction_Run(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		blogURL string
+		want    attr.Value
+		wantErr bool
+	}{
+		{
+			blogURL: "https://staff.hatenablog.com/",
+			want: types.ObjectValueMust(parseBlogURLFunctionAttributeTypes, map[string]attr.Value{
+				"blog_host":    types.StringValue("staff.hatenablog.com"),
+				"subdirectory": types.BoolValue(false),
+			}),
+		},
+		{
+			blogURL: "https://0123456789.hatenablog-oem.com",
+			want: types.ObjectValueMust(parseBlogURLFunctionAttributeTypes, map[string]attr.Value{
+				"blog_host":    types.StringValue("0123456789"),
+				"subdirectory": types.BoolValue(true),
+			}),
+		},
+		{
+			blogURL: "https://staff.hatenablog.com/entry/2024/01/01/000000",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.blogURL, func(t *testing.T) {
+			req := function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(tt.blogURL)}),
+			}
+			resp := function.RunResponse{
+				Result: function.NewResultData(types.ObjectUnknown(parseBlogURLFunctionAttributeTypes)),
+			}
+			(&ParseBlogURLFunction{}).Run(ctx, req, &resp)
+
+			if tt.wantErr {
+				if resp.Error == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			if !resp.Result.Value().Equal(tt.want) {
+				t.Errorf("unexpected result: %s", resp.Result.Value())
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+type BlogMemberListResource struct {
+	client *client.Client
+
+	defaultDeletionProtection bool
+	warnings                  *providerWarnings
+}
+
+type memberListConfigModel struct {
+	Role           types.String `tfsdk:"role"`
+	UsernamePrefix types.String `tfsdk:"username_prefix"`
+}
+
+// ensure that BlogMemberListResource satisfies interfaces
+var (
+	_ list.ListResource              = &BlogMemberListResource{}
+	_ list.ListResourceWithConfigure = &BlogMemberListResource{}
+)
+
+func NewBlogMemberListResource() list.ListResource {
+	return &BlogMemberListResource{}
+}
+
+func (r *BlogMemberListResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_member"
+}
+
+func (r *BlogMemberListResource) ListResourceConfigSchema(_ context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the members of the blog, including the ones not managed by Terraform.",
+		Attributes: map[string]schema.Attribute{
+			"role": schema.StringAttribute{
+				Description: "Only list the members with this role. Must be one of " + roleNamesDescription() + ".",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(roleNames()...),
+				},
+			},
+			"username_prefix": schema.StringAttribute{
+				Description: "Only list the members whose Hatena ID starts with this prefix.",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (r *BlogMemberListResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data := req.ProviderData.(*blogMemberProviderData)
+	r.client = data.Client
+	r.defaultDeletionProtection = data.DeletionProtection
+	r.warnings = data.Warnings
+}
+
+func (r *BlogMemberListResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var config memberListConfigModel
+
+	diags := req.Config.Get(ctx, &config)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	members, err := r.client.ListMembers(ctx)
+	appendStaleAPIKeyWarning(&diags, r.client, r.warnings)
+	appendClockOffsetWarning(&diags, r.client, r.warnings)
+	if err != nil {
+		diags.Append(apiErrorDiagnostic(ctx, r.client, "Failed to list members", err))
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		// 警告は診断だけの結果として、メンバーより先に返す
+		if len(diags) > 0 && !push(list.ListResult{Diagnostics: diags}) {
+			return
+		}
+
+		var count int64
+		for _, member := range members {
+			if !config.Role.IsNull() && member.Role != config.Role.ValueString() {
+				continue
+			}
+			if !strings.HasPrefix(member.Username, config.UsernamePrefix.ValueString()) {
+				continue
+			}
+			if req.Limit > 0 && count >= req.Limit {
+				return
+			}
+			count++
+
+			result := req.NewListResult(ctx)
+			result.DisplayName = fmt.Sprintf("%s (%s)", member.Username, member.Role)
+			result.Diagnostics.Append(result.Identity.Set(ctx, &memberIdentityModel{
+				Owner:    types.StringValue(r.client.Owner()),
+				BlogHost: types.StringValue(r.client.BlogHost()),
+				Username: types.StringValue(member.Username),
+			})...)
+			if req.IncludeResource {
+				model := newMemberResourceModel(r.client, member.Username, member.Role, r.defaultDeletionProtection)
+				result.Diagnostics.Append(result.Resource.Set(ctx, &model)...)
+			}
+
+			if !push(result) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+func TestBlogMemberListResource_List(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/owner/blog.example.com/api/members" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"members":[{"username":"staff-a","role":"admin"},{"username":"staff-b","role":"editor"},{"username":"guest","role":"editor"}]}`)
+	}))
+	defer server.Close()
+
+	c := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+	if err := c.SetEndpoint(server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	r := &BlogMemberListResource{client: c}
+
+	var schemaResp resource.SchemaResponse
+	(&BlogMemberResource{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	var identitySchemaResp resource.IdentitySchemaResponse
+	(&BlogMemberResource{}).IdentitySchema(ctx, resource.IdentitySchemaRequest{}, &identitySchemaResp)
+	var configSchemaResp list.ListResourceSchemaResponse
+	r.ListResourceConfigSchema(ctx, list.ListResourceSchemaRequest{}, &configSchemaResp)
+
+	tests := map[string]struct {
+		role, prefix tftypes.Value
+		limit        int64
+		want         []string
+	}{
+		"all": {
+			role:   tftypes.NewValue(tftypes.String, nil),
+			prefix: tftypes.NewValue(tftypes.String, nil),
+			want:   []string{"staff-a", "staff-b", "guest"},
+		},
+		"role": {
+			role:   tftypes.NewValue(tftypes.String, "editor"),
+			prefix: tftypes.NewValue(tftypes.String, nil),
+			want:   []string{"staff-b", "guest"},
+		},
+		"prefix": {
+			role:   tftypes.NewValue(tftypes.String, nil),
+			prefix: tftypes.NewValue(tftypes.String, "staff-"),
+			want:   []string{"staff-a", "staff-b"},
+		},
+		"role and prefix": {
+			role:   tftypes.NewValue(tftypes.String, "editor"),
+			prefix: tftypes.NewValue(tftypes.String, "staff-"),
+			want:   []string{"staff-b"},
+		},
+		"limit": {
+			role:   tftypes.NewValue(tftypes.String, nil),
+			prefix: tftypes.NewValue(tftypes.String, nil),
+			limit:  1,
+			want:   []string{"staff-a"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			configType := configSchemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+			req := list.ListRequest{
+				Config: tfsdk.Config{
+					Schema: configSchemaResp.Schema,
+					Raw: tftypes.NewValue(configType, map[string]tftypes.Value{
+						"role":            tt.role,
+						"username_prefix": tt.prefix,
+					}),
+				},
+				IncludeResource:        true,
+				Limit:                  tt.limit,
+				ResourceSchema:         schemaResp.Schema,
+				ResourceIdentitySchema: identitySchemaResp.IdentitySchema,
+			}
+			stream := &list.ListResultsStream{}
+			r.List(ctx, req, stream)
+
+			var got []string
+			for result := range stream.Results {
+				if result.Diagnostics.HasError() {
+					t.Fatalf("unexpected diagnostics: %v", result.Diagnostics)
+				}
+
+				var identity memberIdentityModel
+				result.Identity.Get(ctx, &identity)
+				if identity.Owner.ValueString() != "owner" || identity.BlogHost.ValueString() != "blog.example.com" {
+					t.Errorf("unexpected identity: %v", identity)
+				}
+
+				var model memberResourceModel
+				result.Resource.Get(ctx, &model)
+				if model.Username != identity.Username {
+					t.Errorf("unexpected resource: %v", model)
+				}
+				if model.OnDestroy.ValueString() != onDestroyRemove {
+					t.Errorf("unexpected on_destroy: %s", model.OnDestroy)
+				}
+
+				got = append(got, identity.Username.ValueString())
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("unexpected members: %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlogMemberListResource_List_StaleAPIKeyWarning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptedKey(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"members":[{"username":"staff-a","role":"admin"}]}`)
+	}))
+	defer server.Close()
+
+	authenticator := &client.WSSEAuthenticator{Username: "username", APIKey: "stale", FallbackAPIKeys: []string{"current"}}
+	c := client.NewClient("test", "username", "", "owner", "blog.example.com", client.WithAuthenticator(authenticator))
+	if err := c.SetEndpoint(server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	r := &BlogMemberListResource{client: c, warnings: &providerWarnings{}}
+
+	var schemaResp resource.SchemaResponse
+	(&BlogMemberResource{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	var identitySchemaResp resource.IdentitySchemaResponse
+	(&BlogMemberResource{}).IdentitySchema(ctx, resource.IdentitySchemaRequest{}, &identitySchemaResp)
+	var configSchemaResp list.ListResourceSchemaResponse
+	r.ListResourceConfigSchema(ctx, list.ListResourceSchemaRequest{}, &configSchemaResp)
+	configType := configSchemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	req := list.ListRequest{
+		Config: tfsdk.Config{
+			Schema: configSchemaResp.Schema,
+			Raw: tftypes.NewValue(configType, map[string]tftypes.Value{
+				"role":            tftypes.NewValue(tftypes.String, nil),
+				"username_prefix": tftypes.NewValue(tftypes.String, nil),
+			}),
+		},
+		ResourceSchema:         schemaResp.Schema,
+		ResourceIdentitySchema: identitySchemaResp.IdentitySchema,
+	}
+
+	// 警告はプロバイダの設定ごとに一度だけ、メンバーより先に返す
+	for i, wantWarnings := range []int{1, 0} {
+		stream := &list.ListResultsStream{}
+		r.List(ctx, req, stream)
+
+		var warnings, members int
+		for result := range stream.Results {
+			if result.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", result.Diagnostics)
+			}
+			if result.Identity == nil {
+				if members > 0 || result.Diagnostics.WarningsCount() != 1 || result.Diagnostics.Warnings()[0].Summary() != "Stale API key" {
+					t.Errorf("unexpected result: %v", result.Diagnostics)
+				}
+				warnings++
+				continue
+			}
+			members++
+		}
+
+		if warnings != wantWarnings || members != 1 {
+			t.Errorf("list #%d: unexpected %d warnings and %d members", i, warnings, members)
+		}
+	}
+}
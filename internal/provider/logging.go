@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+// httpLogSubsystem はAPIへのリクエストを出力する tflog のサブシステム
+// ログのレベルは TF_LOG_PROVIDER_HATENABLOG で設定する
+const httpLogSubsystem = "hatenablog"
+
+// credentialPatterns は認証情報として値を伏せる X-WSSE と Authorization ヘッダの部分
+var credentialPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(PasswordDigest|Nonce)="[^"]*"`),
+	regexp.MustCompile(`oauth_(signature|token|consumer_key|nonce)="[^"]*"`),
+}
+
+// newHTTPLogger は client のログを tflog のサブシステムに出力する slog.Logger を返す
+// ログに含まれる secrets と認証情報は伏せる
+func newHTTPLogger(secrets ...string) *slog.Logger {
+	var masked []string
+	for _, secret := range secrets {
+		if secret != "" {
+			masked = append(masked, secret)
+		}
+	}
+	return slog.New(&tflogHandler{secrets: masked})
+}
+
+// tflogHandler は slog のログを tflog のサブシステムに出力する slog.Handler
+// tflog のロガーはリクエストの context に含まれるので、ログのたびにサブシステムを作る
+type tflogHandler struct {
+	secrets []string
+	attrs   []slog.Attr
+	group   string
+}
+
+// ensure that tflogHandler satisfies interfaces
+var _ slog.Handler = &tflogHandler{}
+
+// Enabled はレベルによらず true を返す。出力するかどうかは tflog がレベルに従って決める
+func (h *tflogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *tflogHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = tflog.NewSubsystem(ctx, httpLogSubsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER", "HATENABLOG"))
+	ctx = tflog.SubsystemMaskAllFieldValuesRegexes(ctx, httpLogSubsystem, credentialPatterns...)
+	ctx = tflog.SubsystemMaskMessageRegexes(ctx, httpLogSubsystem, credentialPatterns...)
+	if len(h.secrets) > 0 {
+		ctx = tflog.SubsystemMaskAllFieldValuesStrings(ctx, httpLogSubsystem, h.secrets...)
+		ctx = tflog.SubsystemMaskMessageStrings(ctx, httpLogSubsystem, h.secrets...)
+	}
+
+	fields := make(map[string]interface{}, len(h.attrs)+record.NumAttrs())
+	for _, attr := range h.attrs {
+		addLogField(fields, "", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addLogField(fields, h.group, attr)
+		return true
+	})
+
+	switch {
+	case record.Level <= client.LevelTrace:
+		tflog.SubsystemTrace(ctx, httpLogSubsystem, record.Message, fields)
+	case record.Level <= slog.LevelDebug:
+		tflog.SubsystemDebug(ctx, httpLogSubsystem, record.Message, fields)
+	case record.Level <= slog.LevelInfo:
+		tflog.SubsystemInfo(ctx, httpLogSubsystem, record.Message, fields)
+	case record.Level <= slog.LevelWarn:
+		tflog.SubsystemWarn(ctx, httpLogSubsystem, record.Message, fields)
+	default:
+		tflog.SubsystemError(ctx, httpLogSubsystem, record.Message, fields)
+	}
+	return nil
+}
+
+func (h *tflogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, attr := range attrs {
+		attr.Key = joinLogKey(h.group, attr.Key)
+		handler.attrs = append(handler.attrs, attr)
+	}
+	return &handler
+}
+
+func (h *tflogHandler) WithGroup(name string) slog.Handler {
+	handler := *h
+	if name != "" {
+		handler.group = joinLogKey(h.group, name)
+	}
+	return &handler
+}
+
+// addLogField は slog の属性を tflog のフィールドに加える
+// tflog はトップレベルの文字列のフィールドだけを伏せるので、グループは "." でつないだキーに展開し、値は文字列にする
+func addLogField(fields map[string]interface{}, group string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		for _, a := range value.Group() {
+			addLogField(fields, joinLogKey(group, attr.Key), a)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+
+	key := joinLogKey(group, attr.Key)
+	switch value.Kind() {
+	case slog.KindInt64:
+		fields[key] = value.Int64()
+	case slog.KindUint64:
+		fields[key] = value.Uint64()
+	case slog.KindBool:
+		fields[key] = value.Bool()
+	case slog.KindDuration:
+		fields[key] = value.Duration().String()
+	default:
+		fields[key] = value.String()
+	}
+}
+
+func joinLogKey(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return group + "." + key
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+func TestNewHTTPLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 認証情報を含むヘッダや本文がそのまま返されても伏せられる
+		w.Header().Set("X-Echo", r.Header.Get("X-WSSE")+r.Header.Get("Authorization"))
+		fmt.Fprintf(w, `{"members":[],"echo":%q}`, r.Header.Get("X-WSSE")+r.Header.Get("Authorization")+" apikey-secret")
+	}))
+	t.Cleanup(server.Close)
+
+	secrets := []string{"apikey-secret", "fallback-secret", "consumer-secret", "token-secret"}
+	tests := []struct {
+		name          string
+		authenticator client.Authenticator
+		level         string
+		wantMessages  []string
+	}{
+		{
+			name:          "wsse",
+			authenticator: &client.WSSEAuthenticator{Username: "username", APIKey: "apikey-secret", FallbackAPIKeys: []string{"fallback-secret"}},
+			level:         "TRACE",
+			wantMessages:  []string{"request to the Hatena Blog API", "request to the Hatena Blog API with headers and bodies"},
+		},
+		{
+			name:          "oauth",
+			authenticator: &client.OAuth1Authenticator{ConsumerKey: "consumer-key", ConsumerSecret: "consumer-secret", Token: "token", TokenSecret: "token-secret"},
+			level:         "TRACE",
+			wantMessages:  []string{"request to the Hatena Blog API", "request to the Hatena Blog API with headers and bodies"},
+		},
+		{
+			name:          "debug",
+			authenticator: &client.WSSEAuthenticator{Username: "username", APIKey: "apikey-secret"},
+			level:         "DEBUG",
+			wantMessages:  []string{"request to the Hatena Blog API"},
+		},
+		{
+			name:          "off",
+			authenticator: &client.WSSEAuthenticator{Username: "username", APIKey: "apikey-secret"},
+			level:         "OFF",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TF_LOG_PROVIDER_HATENABLOG", tt.level)
+
+			var output bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &output)
+			c := client.NewClient("test", "username", "apikey-secret", "owner", "blog.example.com",
+				client.WithAuthenticator(tt.authenticator), client.WithLogger(newHTTPLogger(secrets...)), client.WithCache(false))
+			if err := c.SetEndpoint(server.URL); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if _, err := c.ListMembers(ctx); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			entries, err := tflogtest.MultilineJSONDecode(&output)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var messages []string
+			for _, entry := range entries {
+				messages = append(messages, entry["@message"].(string))
+				if entry["@module"] != "provider.hatenablog" {
+					t.Errorf("unexpected module: %v", entry["@module"])
+				}
+				if entry["@message"] == "request to the Hatena Blog API" && (entry["method"] != "GET" || entry["status"] == nil || entry["duration"] == nil || entry["response_size"] == nil) {
+					t.Errorf("unexpected fields: %v", entry)
+				}
+			}
+			if strings.Join(messages, "\n") != strings.Join(tt.wantMessages, "\n") {
+				t.Errorf("unexpected messages: %q, want %q", messages, tt.wantMessages)
+			}
+
+			log := output.String()
+			for _, secret := range append(secrets, "PasswordDigest=\"", "oauth_signature=\"") {
+				if strings.Contains(log, secret) {
+					t.Errorf("%q should not be logged: %s", secret, log)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+type blogMemberPolicyModel struct {
+	AllowedUsernamePatterns  types.List  `tfsdk:"allowed_username_patterns"`
+	MaxAdmins                types.Int64 `tfsdk:"max_admins"`
+	ForbiddenRolesForPattern types.Map   `tfsdk:"forbidden_roles_for_pattern"`
+	RequireReasonForAdmin    types.Bool  `tfsdk:"require_reason_for_admin"`
+}
+
+// membershipPolicy はプロバイダの policy ブロックで指定されたメンバーシップのポリシー
+type membershipPolicy struct {
+	allowedUsernamePatterns  []*regexp.Regexp
+	maxAdmins                int64 // 0以下のときは制限しない
+	forbiddenRolesForPattern []forbiddenRoles
+	requireReasonForAdmin    bool
+}
+
+type forbiddenRoles struct {
+	pattern *regexp.Regexp
+	roles   []string
+}
+
+// newMembershipPolicy は policy ブロックの設定からポリシーを組み立てる
+// policy ブロックが指定されていないときは nil を返す
+func newMembershipPolicy(ctx context.Context, m *blogMemberPolicyModel, aliases roleAliases) (*membershipPolicy, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if m == nil {
+		return nil, diags
+	}
+
+	policy := &membershipPolicy{
+		maxAdmins:             m.MaxAdmins.ValueInt64(),
+		requireReasonForAdmin: m.RequireReasonForAdmin.ValueBool(),
+	}
+
+	var patterns []string
+	diags.Append(m.AllowedUsernamePatterns.ElementsAs(ctx, &patterns, false)...)
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("policy").AtName("allowed_username_patterns").AtListIndex(i),
+				"Invalid username pattern",
+				fmt.Sprintf("cannot compile %q as a regular expression: %s", p, err),
+			)
+			continue
+		}
+		policy.allowedUsernamePatterns = append(policy.allowedUsernamePatterns, re)
+	}
+
+	var forbidden map[string][]string
+	diags.Append(m.ForbiddenRolesForPattern.ElementsAs(ctx, &forbidden, false)...)
+	for p, names := range forbidden {
+		attrPath := path.Root("policy").AtName("forbidden_roles_for_pattern").AtMapKey(p)
+		re, err := regexp.Compile(p)
+		if err != nil {
+			diags.AddAttributeError(
+				attrPath,
+				"Invalid username pattern",
+				fmt.Sprintf("cannot compile %q as a regular expression: %s", p, err),
+			)
+			continue
+		}
+
+		// リソースの role と同じように、日本語の名前や別名もAPIでの値に変換して比較する
+		roles := make([]string, 0, len(names))
+		for i, name := range names {
+			role, ok := aliases.normalize(name)
+			if !ok {
+				diags.AddAttributeError(
+					attrPath.AtListIndex(i),
+					"Invalid role",
+					fmt.Sprintf("Role must be one of %s, their Japanese labels, or an alias defined in the provider's 'role_aliases', got: %q", roleNamesDescription(), name),
+				)
+				continue
+			}
+			roles = append(roles, role)
+		}
+		policy.forbiddenRolesForPattern = append(policy.forbiddenRolesForPattern, forbiddenRoles{
+			pattern: re,
+			roles:   roles,
+		})
+	}
+
+	return policy, diags
+}
+
+// check はメンバーの計画がポリシーに違反していないかを検証する
+// role はAPIでの値。reason が unknown のときは require_reason_for_admin だけ検証しない
+// members は現在のブログのメンバー一覧で、max_admins の検証に使われる
+// 同じplanで他のリソースが追加する管理者は members に含まれないので数えない
+func (p *membershipPolicy) check(username, role string, reason types.String, members []*client.BlogMember) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if len(p.allowedUsernamePatterns) > 0 {
+		allowed := false
+		for _, re := range p.allowedUsernamePatterns {
+			if re.MatchString(username) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			patterns := make([]string, 0, len(p.allowedUsernamePatterns))
+			for _, re := range p.allowedUsernamePatterns {
+				patterns = append(patterns, re.String())
+			}
+			diags.AddAttributeError(
+				path.Root("username"),
+				"Username not allowed by policy",
+				fmt.Sprintf("%s does not match any of the allowed username patterns: %s", username, strings.Join(patterns, ", ")),
+			)
+		}
+	}
+
+	for _, f := range p.forbiddenRolesForPattern {
+		if !f.pattern.MatchString(username) {
+			continue
+		}
+		for _, r := range f.roles {
+			if r == role {
+				diags.AddAttributeError(
+					path.Root("role"),
+					"Role forbidden by policy",
+					fmt.Sprintf("%s matches the pattern %q and cannot be given the role '%s'.", username, f.pattern.String(), role),
+				)
+			}
+		}
+	}
+
+	if role == roleAdmin && p.requireReasonForAdmin && !reason.IsUnknown() && reason.ValueString() == "" {
+		diags.AddAttributeError(
+			path.Root("reason"),
+			"Reason required by policy",
+			fmt.Sprintf("A reason must be given to make %s an admin.", username),
+		)
+	}
+
+	if role == roleAdmin && p.maxAdmins > 0 {
+		admins := 1
+		for _, member := range members {
+			if member.Username != username && member.Role == roleAdmin {
+				admins++
+			}
+		}
+		if int64(admins) > p.maxAdmins {
+			diags.AddAttributeError(
+				path.Root("role"),
+				"Too many admins",
+				fmt.Sprintf("Making %s an admin would result in %d admins on the blog, but the policy allows at most %d.", username, admins, p.maxAdmins),
+			)
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+func TestMembershipPolicy_check(t *testing.T) {
+	policy := &membershipPolicy{
+		allowedUsernamePatterns: []*regexp.Regexp{
+			regexp.MustCompile(`^hatena-`),
+			regexp.MustCompile(`^ext-`),
+		},
+		maxAdmins: 2,
+		forbiddenRolesForPattern: []forbiddenRoles{
+			{pattern: regexp.MustCompile(`^ext-`), roles: []string{"admin"}},
+		},
+		requireReasonForAdmin: true,
+	}
+	members := []*client.BlogMember{
+		{Username: "hatena-a", Role: "admin"},
+		{Username: "hatena-b", Role: "editor"},
+	}
+
+	tests := map[string]struct {
+		username, role string
+		reason         types.String
+		wantErrors     int
+	}{
+		"allowed editor":           {username: "hatena-c", role: "editor"},
+		"allowed admin":            {username: "hatena-c", role: "admin", reason: types.StringValue("on-call")},
+		"existing admin":           {username: "hatena-a", role: "admin", reason: types.StringValue("owner")},
+		"disallowed username":      {username: "someone", role: "editor", wantErrors: 1},
+		"forbidden role":           {username: "ext-a", role: "admin", reason: types.StringValue("campaign"), wantErrors: 1},
+		"external contributor":     {username: "ext-a", role: "contributor"},
+		"admin without reason":     {username: "hatena-c", role: "admin", wantErrors: 1},
+		"multiple policy failure":  {username: "ext-a", role: "admin", wantErrors: 2},
+		"unknown reason":           {username: "hatena-c", role: "admin", reason: types.StringUnknown()},
+		"forbidden unknown reason": {username: "ext-a", role: "admin", reason: types.StringUnknown(), wantErrors: 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			diags := policy.check(tt.username, tt.role, tt.reason, members)
+			if diags.ErrorsCount() != tt.wantErrors {
+				t.Errorf("unexpected diagnostics: %v", diags)
+			}
+		})
+	}
+
+	t.Run("max admins", func(t *testing.T) {
+		members := append(members, &client.BlogMember{Username: "hatena-d", Role: "admin"})
+		diags := policy.check("hatena-c", "admin", types.StringValue("on-call"), members)
+		if diags.ErrorsCount() != 1 {
+			t.Errorf("unexpected diagnostics: %v", diags)
+		}
+	})
+}
+
+func TestNewMembershipPolicy_forbiddenRoles(t *testing.T) {
+	aliases := roleAliases{"owner": roleAdmin}
+
+	tests := map[string]struct {
+		roles      []string
+		want       []string
+		wantErrors int
+	}{
+		"names":   {roles: []string{"admin", "editor"}, want: []string{roleAdmin, roleEditor}},
+		"labels":  {roles: []string{"管理者", "寄稿者"}, want: []string{roleAdmin, roleContributor}},
+		"aliases": {roles: []string{"owner"}, want: []string{roleAdmin}},
+		"unknown": {roles: []string{"admin", "superuser"}, want: []string{roleAdmin}, wantErrors: 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			roles := make([]attr.Value, 0, len(tt.roles))
+			for _, role := range tt.roles {
+				roles = append(roles, types.StringValue(role))
+			}
+			m := &blogMemberPolicyModel{
+				AllowedUsernamePatterns: types.ListNull(types.StringType),
+				MaxAdmins:               types.Int64Null(),
+				ForbiddenRolesForPattern: types.MapValueMust(types.ListType{ElemType: types.StringType}, map[string]attr.Value{
+					"^ext-": types.ListValueMust(types.StringType, roles),
+				}),
+				RequireReasonForAdmin: types.BoolNull(),
+			}
+
+			policy, diags := newMembershipPolicy(ctx, m, aliases)
+			if diags.ErrorsCount() != tt.wantErrors {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if got := policy.forbiddenRolesForPattern[0].roles; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected roles: %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
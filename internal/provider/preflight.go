@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+// maxClockSkew はWSSE認証が失敗しうるサーバーとの時計のずれ
+const maxClockSkew = 5 * time.Minute
+
+// preflight はプロバイダの認証情報で対象のブログのメンバーを管理できることを確認する
+// リソースごとにエラーが出る前に、プロバイダの設定の問題を一つのエラーとして報告するために使う
+// 警告は w で共有し、リソースの操作で同じ警告を繰り返さない
+func preflight(ctx context.Context, c *client.Client, w *providerWarnings) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	members, err := c.ListMembers(ctx)
+	appendStaleAPIKeyWarning(&diags, c, w)
+
+	// 時計がずれているとWSSE認証に失敗するので、APIのエラーより先に報告する
+	// クライアントが時計のずれを補正してもなお失敗したときだけエラーにする
+	if skew, ok := c.ClockSkew(); ok && err != nil && (skew > maxClockSkew || skew < -maxClockSkew) {
+		diags.AddError(
+			"Clock skew too large",
+			fmt.Sprintf("The local clock differs from the server's clock by %s, which breaks WSSE authentication. Synchronize the clock of the machine running Terraform, e.g. with NTP.\n\n"+
+				"ローカルの時計がサーバーの時計と %s ずれているため、WSSE認証に失敗します。NTPなどでTerraformを実行するマシンの時計を合わせてください。",
+				skew.Round(time.Second), skew.Round(time.Second)),
+		)
+		return diags
+	}
+	appendClockOffsetWarning(&diags, c, w)
+
+	if err != nil {
+		diags.Append(providerAPIErrorDiagnostic(ctx, c, "Failed to validate credentials", err))
+		return diags
+	}
+
+	// オーナーは常にメンバーを管理できる
+	if c.Username() == c.Owner() {
+		return diags
+	}
+	for _, member := range members {
+		if member.Username != c.Username() {
+			continue
+		}
+		if role, ok := findRole(member.Role); ok && role.CanManageMembers {
+			return diags
+		}
+	}
+
+	blog := fmt.Sprintf("%s/%s", c.Owner(), c.BlogHost())
+	diags.AddAttributeError(
+		path.Root("username"),
+		"Operator lacks admin privileges",
+		fmt.Sprintf("%q is neither the owner nor an admin of the blog %s, so it cannot manage members. 'username' in the provider configuration must be the owner or an admin of the blog.\n\n"+
+			"%q はブログ %s のオーナーでも管理者でもないため、メンバーを管理できません。プロバイダ設定の 'username' にはブログのオーナーか管理者のはてなIDを指定してください。",
+			c.Username(), blog, c.Username(), blog),
+	)
+	return diags
+}
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+func TestPreflight(t *testing.T) {
+	members := `{"members":[{"username":"admin","role":"admin"},{"username":"editor","role":"editor"}]}`
+
+	tests := []struct {
+		name        string
+		username    string
+		handler     http.HandlerFunc
+		wantPath    path.Path
+		wantErr     string
+		wantWarning string
+	}{
+		{
+			name:     "owner",
+			username: "owner",
+			handler:  func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, members) },
+		},
+		{
+			name:     "admin",
+			username: "admin",
+			handler:  func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, members) },
+		},
+		{
+			name:     "editor",
+			username: "editor",
+			handler:  func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, members) },
+			wantPath: path.Root("username"),
+			wantErr:  "Operator lacks admin privileges",
+		},
+		{
+			name:     "unauthorized",
+			username: "owner",
+			handler:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnauthorized) },
+			wantPath: path.Root("apikey"),
+			wantErr:  "Invalid API key",
+		},
+		{
+			name:     "blog not found",
+			username: "admin",
+			handler:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) },
+			wantPath: path.Root("owner"),
+			wantErr:  "Blog not found for the owner",
+		},
+		{
+			// 時計のずれによって認証に失敗したときは、時計のずれを報告する
+			name:     "clock skew",
+			username: "owner",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Date", time.Now().Add(-10*time.Minute).UTC().Format(http.TimeFormat))
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			wantErr: "Clock skew too large",
+		},
+		{
+			// 時計のずれを補正して認証に成功したときは警告する
+			name:     "clock skew corrected",
+			username: "owner",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				serverNow := time.Now().Add(-10 * time.Minute)
+				w.Header().Set("Date", serverNow.UTC().Format(http.TimeFormat))
+				m := regexp.MustCompile(`Created="([^"]+)"`).FindStringSubmatch(r.Header.Get("X-WSSE"))
+				if created, err := time.Parse(time.RFC3339, m[1]); err != nil || created.Sub(serverNow).Abs() > maxClockSkew {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				fmt.Fprint(w, members)
+			},
+			wantWarning: "Clock skew corrected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			c := client.NewClient("test", tt.username, "apikey", "owner", "blog.example.com")
+			if err := c.SetEndpoint(server.URL); err != nil {
+				t.Fatal(err)
+			}
+
+			diags := preflight(context.Background(), c, &providerWarnings{})
+			if tt.wantWarning != "" {
+				if diags.WarningsCount() != 1 || diags.Warnings()[0].Summary() != tt.wantWarning {
+					t.Errorf("unexpected warnings: %v", diags)
+				}
+			} else if diags.WarningsCount() != 0 {
+				t.Errorf("unexpected warnings: %v", diags)
+			}
+			if tt.wantErr == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+				return
+			}
+
+			if diags.ErrorsCount() != 1 {
+				t.Fatalf("expected a single error, got: %v", diags)
+			}
+			d := diags.Errors()[0]
+			if d.Summary() != tt.wantErr {
+				t.Errorf("unexpected summary: %s", d.Summary())
+			}
+			var gotPath path.Path
+			if withPath, ok := d.(diag.DiagnosticWithPath); ok {
+				gotPath = withPath.Path()
+			}
+			if !gotPath.Equal(tt.wantPath) {
+				t.Errorf("unexpected path: %s", gotPath)
+			}
+		})
+	}
+}
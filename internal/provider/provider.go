@@ -2,35 +2,87 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
 )
 
 type blogMemberProvider struct {
 	version string
+
+	// clientOptions はクライアントに追加で渡すオプション。テストで記録したレスポンスを返すトランスポートを渡す
+	clientOptions []client.Option
 }
 
 type blogMemberProviderModel struct {
-	Username       types.String `tfsdk:"username"`
-	Owner          types.String `tfsdk:"owner"`
-	Apikey         types.String `tfsdk:"apikey"`
+	Username        types.String `tfsdk:"username"`
+	Owner           types.String `tfsdk:"owner"`
+	Apikey          types.String `tfsdk:"apikey"`
+	ApikeyFallbacks types.List   `tfsdk:"apikey_fallbacks"`
+
+	OAuthConsumerKey    types.String `tfsdk:"oauth_consumer_key"`
+	OAuthConsumerSecret types.String `tfsdk:"oauth_consumer_secret"`
+	OAuthToken          types.String `tfsdk:"oauth_token"`
+	OAuthTokenSecret    types.String `tfsdk:"oauth_token_secret"`
+
 	BlogHost       types.String `tfsdk:"blog_host"`
+	BlogURL        types.String `tfsdk:"blog_url"`
+	Endpoint       types.String `tfsdk:"endpoint"`
 	HatenablogHost types.String `tfsdk:"hatenablog_host"`
 	Insecure       types.Bool   `tfsdk:"insecure"`
+	CACertFile     types.String `tfsdk:"ca_cert_file"`
+	CACertPEM      types.String `tfsdk:"ca_cert_pem"`
+	ProxyURL       types.String `tfsdk:"proxy_url"`
+	RequestTimeout types.String `tfsdk:"request_timeout"`
+	HTTPTraceFile  types.String `tfsdk:"http_trace_file"`
+
+	DeletionProtection  types.Bool             `tfsdk:"deletion_protection"`
+	SkipRosterCheck     types.Bool             `tfsdk:"skip_roster_check"`
+	ValidateCredentials types.Bool             `tfsdk:"validate_credentials"`
+	RoleAliases         types.Map              `tfsdk:"role_aliases"`
+	Policy              *blogMemberPolicyModel `tfsdk:"policy"`
 }
 
 type blogMemberProviderData struct {
 	Client *client.Client
+
+	// DeletionProtection はリソースで deletion_protection が指定されていないときのデフォルト値
+	DeletionProtection bool
+
+	// Policy はメンバーシップのポリシー。policy ブロックが指定されていないときは nil
+	Policy *membershipPolicy
+
+	// SkipRosterCheck はplan後にメンバー一覧が変更されていないかの確認を省略するかどうか
+	SkipRosterCheck bool
+
+	// RoleAliases は role_aliases で設定されたロールの別名
+	RoleAliases roleAliases
+
+	// Warnings はリソースとデータソースで一度だけ出す警告を出したかどうか
+	Warnings *providerWarnings
 }
 
 // ensure that blogMemberProvider implements the provider.Provider interface
 var (
-	_ provider.Provider = &blogMemberProvider{}
+	_ provider.Provider                  = &blogMemberProvider{}
+	_ provider.ProviderWithListResources = &blogMemberProvider{}
+	_ provider.ProviderWithFunctions     = &blogMemberProvider{}
 )
 
 func New(version string) func() provider.Provider {
@@ -48,7 +100,7 @@ func (p *blogMemberProvider) Metadata(_ context.Context, _ provider.MetadataRequ
 
 func (p *blogMemberProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: "A terraform provider which allows you to manage the members of a Hatena Blog.",
+		Description: "A terraform provider which allows you to manage the members of a Hatena Blog. Requests to the Hatena Blog API are logged with the credentials masked: set TF_LOG_PROVIDER_HATENABLOG to DEBUG to log the method, the URL, the status, the duration and the response size, or to TRACE to log the headers and the bodies as well.",
 		Attributes: map[string]schema.Attribute{
 			"username": schema.StringAttribute{
 				Description: "The Hatena ID of the operator who owns the target blog or has administrative privileges for it.",
@@ -59,21 +111,142 @@ func (p *blogMemberProvider) Schema(_ context.Context, _ provider.SchemaRequest,
 				Optional:    true,
 			},
 			"apikey": schema.StringAttribute{
-				Description: "The API key of the operator. Please visit https://blog.hatena.ne.jp/-/config to obtain your API key.",
-				Required:    true,
+				Description: "The API key of the operator. Please visit https://blog.hatena.ne.jp/-/config to obtain your API key. Either this or the 'oauth_*' attributes are required.",
+				Optional:    true,
+				Sensitive:   true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(oauthAttributePaths()...),
+				},
+			},
+			"apikey_fallbacks": schema.ListAttribute{
+				Description: "API keys tried in order when 'apikey' is rejected, e.g. the previous API key while rotating it. The first accepted one is used for the rest of the run, and a warning tells which API key is stale.",
+				ElementType: types.StringType,
+				Optional:    true,
 				Sensitive:   true,
+				Validators: []validator.List{
+					listvalidator.AlsoRequires(path.MatchRoot("apikey")),
+					listvalidator.ValueStringsAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
+			"oauth_consumer_key": schema.StringAttribute{
+				Description: "The consumer key of the OAuth application. If the 'oauth_*' attributes are specified, requests are authenticated with OAuth 1.0a instead of 'apikey'.",
+				Optional:    true,
+				Validators:  oauthValidators(),
+			},
+			"oauth_consumer_secret": schema.StringAttribute{
+				Description: "The consumer secret of the OAuth application.",
+				Optional:    true,
+				Sensitive:   true,
+				Validators:  oauthValidators(),
+			},
+			"oauth_token": schema.StringAttribute{
+				Description: "The access token issued to the operator for the OAuth application.",
+				Optional:    true,
+				Sensitive:   true,
+				Validators:  oauthValidators(),
+			},
+			"oauth_token_secret": schema.StringAttribute{
+				Description: "The access token secret issued to the operator for the OAuth application.",
+				Optional:    true,
+				Sensitive:   true,
+				Validators:  oauthValidators(),
 			},
 			"blog_host": schema.StringAttribute{
-				Description: "The domain name or host part of the target Hatena blog's URL. For regular blogs, this should be the domain name like 'staff.hatenablog.com'. If using the subdirectory option, this should be like '0123456789' for a blog URL like 'https://0123456789.hatenablog-oem.com'.",
-				Required:    true,
+				Description: "The domain name or host part of the target Hatena blog's URL. For regular blogs, this should be the domain name like 'staff.hatenablog.com'. If using the subdirectory option, this should be like '0123456789' for a blog URL like 'https://0123456789.hatenablog-oem.com'. Either this or 'blog_url' is required.",
+				Optional:    true,
+			},
+			"blog_url": schema.StringAttribute{
+				Description: "The URL of the top page of the target Hatena blog, e.g. 'https://staff.hatenablog.com/', a custom domain or 'https://0123456789.hatenablog-oem.com' for a blog using the subdirectory option. 'blog_host' is derived from it. Either this or 'blog_host' is required.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("blog_host")),
+					blogURLValidator{},
+				},
+			},
+			"deletion_protection": schema.BoolAttribute{
+				Description: "The default value of 'deletion_protection' for member resources which do not specify it. Defaults to false.",
+				Optional:    true,
+			},
+			"skip_roster_check": schema.BoolAttribute{
+				Description: "Whether to skip checking that the members of the blog have not been changed by someone else between plan and apply. The check applies to updates and destroys; new member resources are instead checked against the policy again with the members at apply time. Defaults to false.",
+				Optional:    true,
+			},
+			"role_aliases": schema.MapAttribute{
+				Description: "A map from an alias to the role it stands for, e.g. `{ writer = \"contributor\" }`. Member resources accept the aliases as 'role' in addition to the roles and their Japanese labels.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					mapvalidator.ValueStringsAre(stringvalidator.OneOf(roleNames()...)),
+				},
+			},
+			"validate_credentials": schema.BoolAttribute{
+				Description: "Whether to check that the credentials are valid and the operator can manage the members of the blog when the provider is configured, failing fast with a single error instead of one error per resource. It also reports a clock skew large enough to break the authentication. Defaults to false.",
+				Optional:    true,
+			},
+			"endpoint": schema.StringAttribute{
+				Description: "The URL of the Hatena Blog API, including the scheme and optionally a port and a path prefix, e.g. 'http://localhost:8080/staging'. Defaults to 'https://blog.hatena.ne.jp'.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("hatenablog_host"), path.MatchRoot("insecure")),
+				},
+			},
+			"ca_cert_file": schema.StringAttribute{
+				Description: "The path to a PEM encoded file of CA certificates to trust in addition to the system's, e.g. the CA of an intercepting proxy.",
+				Optional:    true,
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				Description: "PEM encoded CA certificates to trust in addition to the system's, e.g. the CA of an intercepting proxy.",
+				Optional:    true,
+			},
+			"proxy_url": schema.StringAttribute{
+				Description: "The URL of the proxy to send the requests to the API through, e.g. 'http://proxy.example.com:8080'. Defaults to the proxy given by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.",
+				Optional:    true,
+			},
+			"request_timeout": schema.StringAttribute{
+				Description: "The time limit for each request to the API, e.g. '30s'. Defaults to no limit other than the timeouts of the resources.",
+				Optional:    true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"http_trace_file": schema.StringAttribute{
+				Description: "The path to a file to append the requests to the API and their responses to in HAR 1.2 format, e.g. to attach to a report to Hatena. The values of the X-WSSE, Authorization and Cookie headers are redacted. The file is not locked, so do not use the same file for Terraform runs in parallel. Can also be set with the HATENABLOG_HTTP_TRACE_FILE environment variable.",
+				Optional:    true,
 			},
 			"hatenablog_host": schema.StringAttribute{
-				// for internal use
-				Optional: true,
+				Description:        "The host of the Hatena Blog API. Defaults to 'blog.hatena.ne.jp'.",
+				DeprecationMessage: "Use 'endpoint' instead.",
+				Optional:           true,
 			},
 			"insecure": schema.BoolAttribute{
-				// for internal use
-				Optional: true,
+				Description:        "Whether to access the Hatena Blog API over plain HTTP. Defaults to false.",
+				DeprecationMessage: "Use 'endpoint' instead.",
+				Optional:           true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"policy": schema.SingleNestedBlock{
+				Description: "Guardrails for member resources. Plans violating the policy fail before any API call is made.",
+				Attributes: map[string]schema.Attribute{
+					"allowed_username_patterns": schema.ListAttribute{
+						Description: "Regular expressions of the Hatena IDs which are allowed to be members. If specified, the username of every member resource must match at least one of them.",
+						ElementType: types.StringType,
+						Optional:    true,
+					},
+					"max_admins": schema.Int64Attribute{
+						Description: "The maximum number of admins on the blog, including existing members not managed by Terraform. Each member resource is checked against the current members of the blog, so admins added by other resources in the same apply are not counted, and an apply adding several admins at once can exceed the limit.",
+						Optional:    true,
+					},
+					"forbidden_roles_for_pattern": schema.MapAttribute{
+						Description: "A map from a regular expression of Hatena IDs to the roles which cannot be given to the matching members, e.g. `{ \"^ext-\" = [\"admin\"] }`. The roles may also be given by their Japanese labels or the aliases defined in 'role_aliases'.",
+						ElementType: types.ListType{ElemType: types.StringType},
+						Optional:    true,
+					},
+					"require_reason_for_admin": schema.BoolAttribute{
+						Description: "Whether member resources with the role 'admin' must specify 'reason'.",
+						Optional:    true,
+					},
+				},
 			},
 		},
 	}
@@ -111,35 +284,232 @@ func (p *blogMemberProvider) Configure(ctx context.Context, req provider.Configu
 		return
 	}
 	apikey := config.Apikey.ValueString()
-	if apikey == "" {
-		resp.Diagnostics.AddError("apikey is required", "cannot use empty value for apikey")
+	authenticator, diags := oauthAuthenticator(config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if authenticator == nil && apikey == "" {
+		resp.Diagnostics.AddError("apikey is required", "cannot use empty value for apikey unless the oauth_* attributes are specified")
 		return
 	}
 
+	if config.ApikeyFallbacks.IsUnknown() {
+		resp.Diagnostics.AddError("apikey_fallbacks is unknown", "cannot use unknown value for apikey_fallbacks")
+		return
+	}
+	var apikeyFallbacks []string
+	resp.Diagnostics.Append(config.ApikeyFallbacks.ElementsAs(ctx, &apikeyFallbacks, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if authenticator == nil && len(apikeyFallbacks) > 0 {
+		authenticator = &client.WSSEAuthenticator{
+			Username:        username,
+			APIKey:          apikey,
+			FallbackAPIKeys: apikeyFallbacks,
+		}
+	}
+
 	if config.BlogHost.IsUnknown() {
 		resp.Diagnostics.AddError("blog_host is required", "cannot use unknown value for blog_host")
 		return
 	}
+	if config.BlogURL.IsUnknown() {
+		resp.Diagnostics.AddError("blog_url is unknown", "cannot use unknown value for blog_url")
+		return
+	}
 	blogHost := config.BlogHost.ValueString()
+	if !config.BlogURL.IsNull() {
+		var err error
+		blogHost, _, err = parseBlogURL(config.BlogURL.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("blog_url"), "Invalid Blog URL", err.Error())
+			return
+		}
+	}
 	if blogHost == "" {
 		resp.Diagnostics.AddError("blog_host is required", "cannot use empty value for blog_host")
 		return
 	}
 
-	client := client.NewClient(p.version, username, apikey, owner, blogHost)
+	if config.Endpoint.IsUnknown() || config.HatenablogHost.IsUnknown() || config.Insecure.IsUnknown() {
+		resp.Diagnostics.AddError("endpoint is unknown", "cannot use unknown value for endpoint, hatenablog_host or insecure")
+		return
+	}
 
-	if !config.HatenablogHost.IsNull() {
-		client.SetHatenablogHost(config.HatenablogHost.ValueString())
+	if config.HTTPTraceFile.IsUnknown() {
+		resp.Diagnostics.AddError("http_trace_file is unknown", "cannot use unknown value for http_trace_file")
+		return
 	}
-	if !config.HatenablogHost.IsNull() {
-		client.SetInsecure(config.Insecure.ValueBool())
+	httpTraceFile := os.Getenv("HATENABLOG_HTTP_TRACE_FILE")
+	if !config.HTTPTraceFile.IsNull() {
+		httpTraceFile = config.HTTPTraceFile.ValueString()
+	}
+
+	// 認証情報はリクエストのログに出力しない
+	secrets := append([]string{apikey}, apikeyFallbacks...)
+	secrets = append(secrets, config.OAuthConsumerKey.ValueString(), config.OAuthConsumerSecret.ValueString(), config.OAuthToken.ValueString(), config.OAuthTokenSecret.ValueString())
+	opts := []client.Option{client.WithLogger(newHTTPLogger(secrets...))}
+	if authenticator != nil {
+		opts = append(opts, client.WithAuthenticator(authenticator))
+	}
+	if httpTraceFile != "" {
+		opts = append(opts, client.WithHARFile(httpTraceFile))
+	}
+	opts = append(opts, p.clientOptions...)
+	client := client.NewClient(p.version, username, apikey, owner, blogHost, opts...)
+
+	if err := client.SetEndpoint(providerEndpoint(config)); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("endpoint"), "Invalid endpoint", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(configureTransport(client, config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.RoleAliases.IsUnknown() {
+		resp.Diagnostics.AddError("role_aliases is unknown", "cannot use unknown value for role_aliases")
+		return
+	}
+	var aliases roleAliases
+	resp.Diagnostics.Append(config.RoleAliases.ElementsAs(ctx, &aliases, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	policy, diags := newMembershipPolicy(ctx, config.Policy, aliases)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	warnings := &providerWarnings{}
+	if config.ValidateCredentials.ValueBool() {
+		resp.Diagnostics.Append(preflight(ctx, client, warnings)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
 	data := blogMemberProviderData{
-		Client: client,
+		Client:             client,
+		DeletionProtection: config.DeletionProtection.ValueBool(),
+		Policy:             policy,
+		SkipRosterCheck:    config.SkipRosterCheck.ValueBool(),
+		RoleAliases:        aliases,
+		Warnings:           warnings,
 	}
 	resp.DataSourceData = &data
 	resp.ResourceData = &data
+	resp.ListResourceData = &data
+}
+
+// oauthAttributePaths は OAuth の認証情報の属性
+func oauthAttributePaths() []path.Expression {
+	return []path.Expression{
+		path.MatchRoot("oauth_consumer_key"),
+		path.MatchRoot("oauth_consumer_secret"),
+		path.MatchRoot("oauth_token"),
+		path.MatchRoot("oauth_token_secret"),
+	}
+}
+
+// oauthValidators は OAuth の認証情報の属性が揃って指定されることを検証する
+func oauthValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.AlsoRequires(oauthAttributePaths()...),
+		stringvalidator.LengthAtLeast(1),
+	}
+}
+
+// oauthAuthenticator は OAuth の認証情報が指定されていれば OAuth 1.0a で認証する Authenticator を返す
+// 指定されていないときは nil を返す
+func oauthAuthenticator(config blogMemberProviderModel) (client.Authenticator, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	values := []types.String{config.OAuthConsumerKey, config.OAuthConsumerSecret, config.OAuthToken, config.OAuthTokenSecret}
+	for _, v := range values {
+		if v.IsUnknown() {
+			diags.AddError("OAuth credentials are unknown", "cannot use unknown value for oauth_consumer_key, oauth_consumer_secret, oauth_token or oauth_token_secret")
+			return nil, diags
+		}
+	}
+	if config.OAuthConsumerKey.IsNull() {
+		return nil, diags
+	}
+
+	return &client.OAuth1Authenticator{
+		ConsumerKey:    config.OAuthConsumerKey.ValueString(),
+		ConsumerSecret: config.OAuthConsumerSecret.ValueString(),
+		Token:          config.OAuthToken.ValueString(),
+		TokenSecret:    config.OAuthTokenSecret.ValueString(),
+	}, diags
+}
+
+// providerEndpoint は設定からAPIのURLを決める
+// endpoint が指定されていないときは、非推奨の hatenablog_host と insecure から組み立てる
+func providerEndpoint(config blogMemberProviderModel) string {
+	if !config.Endpoint.IsNull() {
+		return config.Endpoint.ValueString()
+	}
+	if config.HatenablogHost.IsNull() && config.Insecure.IsNull() {
+		return client.DefaultEndpoint
+	}
+
+	endpoint, _ := url.Parse(client.DefaultEndpoint)
+	if !config.HatenablogHost.IsNull() {
+		endpoint.Host = config.HatenablogHost.ValueString()
+	}
+	if config.Insecure.ValueBool() {
+		endpoint.Scheme = "http"
+	}
+	return endpoint.String()
+}
+
+// configureTransport はCA証明書、プロキシ、リクエストのタイムアウトの設定をクライアントに反映する
+func configureTransport(c *client.Client, config blogMemberProviderModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if config.CACertFile.IsUnknown() || config.CACertPEM.IsUnknown() || config.ProxyURL.IsUnknown() || config.RequestTimeout.IsUnknown() {
+		diags.AddError("transport settings are unknown", "cannot use unknown value for ca_cert_file, ca_cert_pem, proxy_url or request_timeout")
+		return diags
+	}
+
+	if !config.CACertFile.IsNull() {
+		pemCerts, err := os.ReadFile(config.CACertFile.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("ca_cert_file"), "Unable to read CA certificates", err.Error())
+			return diags
+		}
+		if err := c.SetCACerts(pemCerts); err != nil {
+			diags.AddAttributeError(path.Root("ca_cert_file"), "Invalid CA certificates", fmt.Sprintf("%s: %s", config.CACertFile.ValueString(), err))
+			return diags
+		}
+	}
+	if !config.CACertPEM.IsNull() {
+		if err := c.SetCACerts([]byte(config.CACertPEM.ValueString())); err != nil {
+			diags.AddAttributeError(path.Root("ca_cert_pem"), "Invalid CA certificates", err.Error())
+			return diags
+		}
+	}
+
+	if !config.ProxyURL.IsNull() {
+		if err := c.SetProxy(config.ProxyURL.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("proxy_url"), "Invalid proxy URL", err.Error())
+			return diags
+		}
+	}
+
+	if !config.RequestTimeout.IsNull() {
+		// 値は durationValidator で検証済み
+		timeout, _ := time.ParseDuration(config.RequestTimeout.ValueString())
+		c.SetRequestTimeout(timeout)
+	}
+
+	return diags
 }
 
 func (p *blogMemberProvider) Resources(ctx context.Context) []func() resource.Resource {
@@ -149,5 +519,21 @@ func (p *blogMemberProvider) Resources(ctx context.Context) []func() resource.Re
 }
 
 func (p *blogMemberProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return nil
+	return []func() datasource.DataSource{
+		NewBlogMembersDataSource,
+		NewBlogOperatorDataSource,
+		NewBlogRolesDataSource,
+	}
+}
+
+func (p *blogMemberProvider) ListResources(ctx context.Context) []func() list.ListResource {
+	return []func() list.ListResource{
+		NewBlogMemberListResource,
+	}
+}
+
+func (p *blogMemberProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewParseBlogURLFunction,
+	}
 }
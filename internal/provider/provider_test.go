@@ -1,8 +1,20 @@
 package provider
 
 import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
 )
 
 const (
@@ -23,3 +35,198 @@ var (
 		"hatenablog-members": providerserver.NewProtocol6WithError(New("test")()),
 	}
 )
+
+// protoV6ProviderFactoriesWithClientOptions はクライアントにオプションを追加で渡すプロバイダーを返す
+func protoV6ProviderFactoriesWithClientOptions(opts ...client.Option) map[string]func() (tfprotov6.ProviderServer, error) {
+	return map[string]func() (tfprotov6.ProviderServer, error){
+		"hatenablog-members": providerserver.NewProtocol6WithError(&blogMemberProvider{version: "test", clientOptions: opts}),
+	}
+}
+
+func TestProviderEndpoint(t *testing.T) {
+	tests := []struct {
+		name   string
+		config blogMemberProviderModel
+		want   string
+	}{
+		{
+			name: "default",
+			config: blogMemberProviderModel{
+				Endpoint:       types.StringNull(),
+				HatenablogHost: types.StringNull(),
+				Insecure:       types.BoolNull(),
+			},
+			want: "https://blog.hatena.ne.jp",
+		},
+		{
+			name: "endpoint",
+			config: blogMemberProviderModel{
+				Endpoint:       types.StringValue("https://staging.example.com/hatenablog"),
+				HatenablogHost: types.StringNull(),
+				Insecure:       types.BoolNull(),
+			},
+			want: "https://staging.example.com/hatenablog",
+		},
+		{
+			name: "hatenablog_host",
+			config: blogMemberProviderModel{
+				Endpoint:       types.StringNull(),
+				HatenablogHost: types.StringValue("staging.example.com"),
+				Insecure:       types.BoolNull(),
+			},
+			want: "https://staging.example.com",
+		},
+		{
+			name: "hatenablog_host and insecure",
+			config: blogMemberProviderModel{
+				Endpoint:       types.StringNull(),
+				HatenablogHost: types.StringValue("localhost:8080"),
+				Insecure:       types.BoolValue(true),
+			},
+			want: "http://localhost:8080",
+		},
+		{
+			// insecure は hatenablog_host がなくても効く
+			name: "insecure",
+			config: blogMemberProviderModel{
+				Endpoint:       types.StringNull(),
+				HatenablogHost: types.StringNull(),
+				Insecure:       types.BoolValue(true),
+			},
+			want: "http://blog.hatena.ne.jp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := providerEndpoint(tt.config); got != tt.want {
+				t.Errorf("unexpected endpoint: %s", got)
+			}
+		})
+	}
+}
+
+func TestConfigureTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"members":[]}`)
+	}))
+	defer server.Close()
+
+	pemCerts := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	caCertFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caCertFile, []byte(pemCerts), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	nullConfig := func() blogMemberProviderModel {
+		return blogMemberProviderModel{
+			CACertFile:     types.StringNull(),
+			CACertPEM:      types.StringNull(),
+			ProxyURL:       types.StringNull(),
+			RequestTimeout: types.StringNull(),
+		}
+	}
+
+	tests := []struct {
+		name      string
+		configure func(*blogMemberProviderModel)
+		wantErr   string
+	}{
+		{
+			name:      "ca_cert_file",
+			configure: func(c *blogMemberProviderModel) { c.CACertFile = types.StringValue(caCertFile) },
+		},
+		{
+			name:      "ca_cert_pem",
+			configure: func(c *blogMemberProviderModel) { c.CACertPEM = types.StringValue(pemCerts) },
+		},
+		{
+			name: "request_timeout",
+			configure: func(c *blogMemberProviderModel) {
+				c.CACertPEM = types.StringValue(pemCerts)
+				c.RequestTimeout = types.StringValue("30s")
+			},
+		},
+		{
+			name: "missing ca_cert_file",
+			configure: func(c *blogMemberProviderModel) {
+				c.CACertFile = types.StringValue(filepath.Join(t.TempDir(), "missing.pem"))
+			},
+			wantErr: "Unable to read CA certificates",
+		},
+		{
+			name:      "invalid ca_cert_pem",
+			configure: func(c *blogMemberProviderModel) { c.CACertPEM = types.StringValue("not a certificate") },
+			wantErr:   "Invalid CA certificates",
+		},
+		{
+			name:      "invalid proxy_url",
+			configure: func(c *blogMemberProviderModel) { c.ProxyURL = types.StringValue("proxy.example.com:8080") },
+			wantErr:   "Invalid proxy URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+			if err := c.SetEndpoint(server.URL); err != nil {
+				t.Fatal(err)
+			}
+			config := nullConfig()
+			tt.configure(&config)
+
+			diags := configureTransport(c, config)
+			if tt.wantErr != "" {
+				if diags.ErrorsCount() != 1 || diags.Errors()[0].Summary() != tt.wantErr {
+					t.Errorf("unexpected diagnostics: %v", diags)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			// 追加したCA証明書でテスト用サーバーの証明書を検証できる
+			if _, err := c.ListMembers(context.Background()); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if !config.RequestTimeout.IsNull() && c.RequestTimeout() != 30*time.Second {
+				t.Errorf("unexpected request timeout: %s", c.RequestTimeout())
+			}
+		})
+	}
+}
+
+func TestOAuthAuthenticator(t *testing.T) {
+	config := blogMemberProviderModel{
+		OAuthConsumerKey:    types.StringNull(),
+		OAuthConsumerSecret: types.StringNull(),
+		OAuthToken:          types.StringNull(),
+		OAuthTokenSecret:    types.StringNull(),
+	}
+	authenticator, diags := oauthAuthenticator(config)
+	if diags.HasError() || authenticator != nil {
+		t.Errorf("unexpected result: %v, %v", authenticator, diags)
+	}
+
+	config.OAuthConsumerKey = types.StringValue("consumer")
+	config.OAuthConsumerSecret = types.StringValue("consumer secret")
+	config.OAuthToken = types.StringValue("token")
+	config.OAuthTokenSecret = types.StringUnknown()
+	if _, diags := oauthAuthenticator(config); !diags.HasError() {
+		t.Errorf("unknown credentials should be an error")
+	}
+
+	config.OAuthTokenSecret = types.StringValue("token secret")
+	authenticator, diags = oauthAuthenticator(config)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	oauth, ok := authenticator.(*client.OAuth1Authenticator)
+	if !ok {
+		t.Fatalf("unexpected authenticator: %T", authenticator)
+	}
+	if oauth.ConsumerKey != "consumer" || oauth.ConsumerSecret != "consumer secret" || oauth.Token != "token" || oauth.TokenSecret != "token secret" {
+		t.Errorf("unexpected credentials: %+v", oauth)
+	}
+}
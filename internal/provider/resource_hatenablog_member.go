@@ -2,13 +2,23 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
@@ -16,17 +26,59 @@ import (
 
 type BlogMemberResource struct {
 	client *client.Client
+
+	defaultDeletionProtection bool
+	policy                    *membershipPolicy
+	skipRosterCheck           bool
+	roleAliases               roleAliases
+	warnings                  *providerWarnings
 }
 
 type memberResourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	Username  types.String `tfsdk:"username"`
+	Role      roleValue    `tfsdk:"role"`
+	OnDestroy types.String `tfsdk:"on_destroy"`
+	ExpiresAt types.String `tfsdk:"expires_at"`
+	Expired   types.Bool   `tfsdk:"expired"`
+
+	DeletionProtection types.Bool   `tfsdk:"deletion_protection"`
+	Reason             types.String `tfsdk:"reason"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+// memberIdentityModel はメンバーのリソースアイデンティティ
+type memberIdentityModel struct {
+	Owner    types.String `tfsdk:"owner"`
+	BlogHost types.String `tfsdk:"blog_host"`
 	Username types.String `tfsdk:"username"`
-	Role     types.String `tfsdk:"role"`
 }
 
+// on_destroy に指定できる値
+const (
+	// onDestroyRemove はリソースの削除時にメンバーをブログから削除する
+	onDestroyRemove = "remove"
+	// onDestroyDemote はリソースの削除時にメンバーを寄稿者に降格してブログに残す
+	onDestroyDemote = "demote"
+	// onDestroyAbandon はリソースの削除時にAPIを呼ばずにstateからのみ削除する
+	onDestroyAbandon = "abandon"
+)
+
+// expiryWarningPeriod は expires_at が近づいていることを警告し始める期間
+const expiryWarningPeriod = 7 * 24 * time.Hour
+
+// timeNow は現在時刻を返す。テストで差し替えられるように変数にしている
+var timeNow = time.Now
+
 // ensure that BlogMemberResource satisfies interfaces
 var (
-	_ resource.Resource                = &BlogMemberResource{}
-	_ resource.ResourceWithImportState = &BlogMemberResource{}
+	_ resource.Resource                   = &BlogMemberResource{}
+	_ resource.ResourceWithImportState    = &BlogMemberResource{}
+	_ resource.ResourceWithModifyPlan     = &BlogMemberResource{}
+	_ resource.ResourceWithIdentity       = &BlogMemberResource{}
+	_ resource.ResourceWithUpgradeState   = &BlogMemberResource{}
+	_ resource.ResourceWithValidateConfig = &BlogMemberResource{}
 )
 
 func NewBlogMemberResource() resource.Resource {
@@ -37,20 +89,84 @@ func (r *BlogMemberResource) Metadata(_ context.Context, req resource.MetadataRe
 	resp.TypeName = req.ProviderTypeName + "_member"
 }
 
-func (r *BlogMemberResource) Schema(_ context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *BlogMemberResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		// 属性を変更したときは Version を上げて UpgradeState に移行処理を追加すること
+		Version: 1,
 		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The ID of the member in the form of '<owner>/<blog_host>/<username>'.",
+				Computed:    true,
+			},
 			"username": schema.StringAttribute{
-				Description: "The Hatena ID of the blog member.",
+				Description: "The Hatena ID of the blog member. Changing it replaces the resource: the previous member is handled according to 'on_destroy' and the new one is added to the blog.",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"role": schema.StringAttribute{
 				Required:    true,
-				Description: "Role of the blog member. Role must be one of 'admin'（管理者）, 'editor'（編集者）, or 'contributor'（寄稿者）.",
+				Description: "Role of the blog member. Role must be one of " + roleNamesDescription() + ". The Japanese labels and the aliases defined in the provider's 'role_aliases' are also accepted as the corresponding role.",
+				// 別名はプロバイダの設定後にしか分からないので、値の検証は ValidateConfig で行う
+				CustomType: roleType{},
+			},
+			"on_destroy": schema.StringAttribute{
+				Description: "What to do with the blog member when this resource is destroyed. Must be one of 'remove' (remove the member from the blog), 'demote' (downgrade the member to 'contributor' and keep them on the blog), or 'abandon' (only remove the resource from the Terraform state, without accessing the API). Defaults to 'remove'.",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(onDestroyRemove),
+				Validators: []validator.String{
+					stringvalidator.OneOf(onDestroyRemove, onDestroyDemote, onDestroyAbandon),
+				},
+			},
+			"expires_at": schema.StringAttribute{
+				Description: "The time at which the membership expires, in RFC3339 format (e.g. '2024-12-31T23:59:59+09:00'). Once it has passed, the next apply removes the member from the blog, or downgrades them to 'contributor' if 'on_destroy' is 'demote'. Removing an expired member fails while 'deletion_protection' is true. If it has already passed when the resource is created, the member is not added to the blog. If it passes between plan and apply, the apply fails with 'Provider produced inconsistent final plan'; plan and apply again to enforce the expiry.",
+				Optional:    true,
 				Validators: []validator.String{
-					stringvalidator.OneOf("admin", "editor", "contributor"),
+					rfc3339Validator{},
 				},
 			},
+			"expired": schema.BoolAttribute{
+				Description: "Whether the membership has expired and the expiry has been enforced on the blog.",
+				Computed:    true,
+			},
+			"deletion_protection": schema.BoolAttribute{
+				Description: "Whether to prevent this resource from being destroyed. While true, destroying the resource fails, so it must first be set to false in a separate apply. It also prevents an expired member from being removed from the blog, although they can still be downgraded when 'on_destroy' is 'demote'. Defaults to the provider's 'deletion_protection'.",
+				Optional:    true,
+				Computed:    true,
+			},
+			"reason": schema.StringAttribute{
+				Description: "Why the member is given the role. Only recorded in the Terraform state, and required for admins when the provider's policy has 'require_reason_for_admin'.",
+				Optional:    true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (r *BlogMemberResource) IdentitySchema(_ context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"owner": identityschema.StringAttribute{
+				Description:       "The Hatena ID of the owner of the blog.",
+				RequiredForImport: true,
+			},
+			"blog_host": identityschema.StringAttribute{
+				Description:       "The host of the blog.",
+				RequiredForImport: true,
+			},
+			"username": identityschema.StringAttribute{
+				Description:       "The Hatena ID of the blog member.",
+				RequiredForImport: true,
+			},
 		},
 	}
 }
@@ -60,10 +176,34 @@ func (r *BlogMemberResource) Configure(_ context.Context, req resource.Configure
 		return
 	}
 
-	r.client = req.ProviderData.(*blogMemberProviderData).Client
+	data := req.ProviderData.(*blogMemberProviderData)
+	r.client = data.Client
+	r.defaultDeletionProtection = data.DeletionProtection
+	r.policy = data.Policy
+	r.skipRosterCheck = data.SkipRosterCheck
+	r.roleAliases = data.RoleAliases
+	r.warnings = data.Warnings
+}
+
+// ValidateConfig はロールがAPIでの値、日本語の名前、プロバイダの role_aliases の別名のいずれかであることを検証する
+// terraform validate ではプロバイダが設定されず別名が分からないので、APIでの値と日本語の名前以外は plan 時に検証する
+func (r *BlogMemberResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var role roleValue
+	diags := req.Config.GetAttribute(ctx, path.Root("role"), &role)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || role.IsNull() || role.IsUnknown() {
+		return
+	}
+	if _, ok := normalizeRole(role.ValueString()); ok || r.client == nil {
+		return
+	}
+	resp.Diagnostics.Append(r.validateRole(role)...)
 }
 
 func (r *BlogMemberResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	defer appendStaleAPIKeyWarning(&resp.Diagnostics, r.client, r.warnings)
+	defer appendClockOffsetWarning(&resp.Diagnostics, r.client, r.warnings)
+
 	var plan memberResourceModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -71,20 +211,57 @@ func (r *BlogMemberResource) Create(ctx context.Context, req resource.CreateRequ
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	plan.ID = types.StringValue(memberID(r.client.Owner(), r.client.BlogHost(), plan.Username.ValueString()))
 
-	res, err := r.client.AddMember(plan.Username.ValueString(), plan.Role.ValueString())
+	timeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := withOperationTimeout(ctx, "create", timeout)
+	defer cancel()
+
+	// 作成時に既に期限切れであれば、メンバーを追加せずに期限切れとして記録する
+	// 期限切れの処理はメンバーに権限を与えないので、APIを呼ぶ必要はない
+	if plan.Expired.ValueBool() {
+		diags = resp.State.Set(ctx, &plan)
+		resp.Diagnostics.Append(diags...)
+		resp.Diagnostics.Append(r.setIdentity(ctx, resp.Identity, plan.Username.ValueString())...)
+		return
+	}
+
+	// フレームワークは plan 時の private state を Create に渡さないので、記録したメンバー一覧とは比較できない
+	// 以降のリソースの確認のためにメンバー一覧を確定させておく
+	resp.Diagnostics.Append(r.checkRoster(ctx, nil)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// 代わりに、plan 後にメンバー一覧が変更されていてもポリシーに違反しないように、現在のメンバー一覧で検証し直す
+	if r.policy != nil {
+		resp.Diagnostics.Append(r.checkPolicy(ctx, &plan)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	res, err := r.client.AddMember(ctx, plan.Username.ValueString(), r.roleAliases.canonical(plan.Role))
 	if err != nil {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("Failed to add member %s: %s", plan.Username.ValueString(), err))
+		resp.Diagnostics.Append(apiErrorDiagnostic(ctx, r.client, fmt.Sprintf("Failed to add member %s", plan.Username.ValueString()), err))
 		return
 	}
 
 	plan.Username = types.StringValue(res.Username)
-	plan.Role = types.StringValue(res.Role)
+	plan.Role = r.roleAliases.stateValue(plan.Role, res.Role)
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(r.setIdentity(ctx, resp.Identity, plan.Username.ValueString())...)
 }
 
 func (r *BlogMemberResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	defer appendStaleAPIKeyWarning(&resp.Diagnostics, r.client, r.warnings)
+	defer appendClockOffsetWarning(&resp.Diagnostics, r.client, r.warnings)
+
 	var state memberResourceModel
 
 	diags := req.State.Get(ctx, &state)
@@ -93,50 +270,142 @@ func (r *BlogMemberResource) Read(ctx context.Context, req resource.ReadRequest,
 		return
 	}
 
-	members, err := r.client.ListMembers()
+	timeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := withOperationTimeout(ctx, "read", timeout)
+	defer cancel()
+
+	members, err := r.client.ListMembers(ctx)
 	if err != nil {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("Failed to list members: %s", err.Error()))
+		resp.Diagnostics.Append(apiErrorDiagnostic(ctx, r.client, "Failed to list members", err))
 		return
 	}
 
+	// apply時にメンバー一覧が変更されていないかを確認するために記録しておく
+	resp.Diagnostics.Append(r.recordRosterFingerprint(ctx, resp.Private, members)...)
+
+	var found *client.BlogMember
 	for _, member := range members {
 		if member.Username == state.Username.ValueString() {
+			found = member
+			break
+		}
+	}
 
-			diags = resp.State.Set(ctx, &memberResourceModel{
-				Username: types.StringValue(member.Username),
-				Role:     types.StringValue(member.Role),
-			})
+	state.ID = types.StringValue(memberID(r.client.Owner(), r.client.BlogHost(), state.Username.ValueString()))
+
+	if state.Expired.ValueBool() {
+		// 期限切れの処理が適用済みのままであれば、roleは設定値のまま維持する
+		if expiryEnforced(&state, found) {
+			diags = resp.State.Set(ctx, &state)
 			resp.Diagnostics.Append(diags...)
+			resp.Diagnostics.Append(r.setIdentity(ctx, resp.Identity, state.Username.ValueString())...)
 			return
 		}
+		// 誰かがメンバーを戻した場合は、次のapplyで再度適用させる
+		state.Expired = types.BoolValue(false)
 	}
 
-	// member not found
-	resp.State.RemoveResource(ctx)
+	if found == nil {
+		// member not found
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	if expiresAt, ok := parseExpiresAt(state.ExpiresAt); ok {
+		if remaining := expiresAt.Sub(timeNow()); 0 < remaining && remaining <= expiryWarningPeriod {
+			resp.Diagnostics.AddWarning(
+				"Membership expires soon",
+				fmt.Sprintf("The membership of %s expires at %s. Update 'expires_at' to extend it.", found.Username, expiresAt.Format(time.RFC3339)),
+			)
+		}
+	}
+
+	state.Username = types.StringValue(found.Username)
+	// APIでの値を保存する。以前の値が同じロールの日本語の名前や別名であれば、設定との差分にならないように維持する
+	// インポートしたときは以前の値がないので、APIでの値になる
+	state.Role = r.roleAliases.stateValue(state.Role, found.Role)
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(r.setIdentity(ctx, resp.Identity, state.Username.ValueString())...)
 }
 
 func (r *BlogMemberResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var plan memberResourceModel
+	defer appendStaleAPIKeyWarning(&resp.Diagnostics, r.client, r.warnings)
+	defer appendClockOffsetWarning(&resp.Diagnostics, r.client, r.warnings)
+
+	var plan, state memberResourceModel
 
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.ID = types.StringValue(memberID(r.client.Owner(), r.client.BlogHost(), plan.Username.ValueString()))
+
+	timeout, diags := plan.Timeouts.Update(ctx, defaultUpdateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := withOperationTimeout(ctx, "update", timeout)
+	defer cancel()
+
+	resp.Diagnostics.Append(r.checkRoster(ctx, req.Private)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	res, err := r.client.AddMember(plan.Username.ValueString(), plan.Role.ValueString())
+	// 記録したメンバー一覧はこの変更によって古くなる
+	resp.Diagnostics.Append(r.recordRosterFingerprint(ctx, resp.Private, nil)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Expired.ValueBool() {
+		// 同じ方法で適用済みであればAPIを呼ぶ必要はない
+		if !state.Expired.ValueBool() || expiryAction(&state) != expiryAction(&plan) {
+			if err := r.enforceExpiry(ctx, &plan); err != nil {
+				resp.Diagnostics.Append(apiErrorDiagnostic(ctx, r.client, fmt.Sprintf("Failed to enforce expiry of member %s", plan.Username.ValueString()), err))
+				return
+			}
+		}
+		diags = resp.State.Set(ctx, &plan)
+		resp.Diagnostics.Append(diags...)
+		resp.Diagnostics.Append(r.setIdentity(ctx, resp.Identity, plan.Username.ValueString())...)
+		return
+	}
+
+	// インポート後に設定の日本語の名前や別名に書き換わるだけのときは、APIを呼ぶ必要はない
+	if !state.Expired.ValueBool() && r.roleAliases.canonical(plan.Role) == r.roleAliases.canonical(state.Role) {
+		diags = resp.State.Set(ctx, &plan)
+		resp.Diagnostics.Append(diags...)
+		resp.Diagnostics.Append(r.setIdentity(ctx, resp.Identity, plan.Username.ValueString())...)
+		return
+	}
+
+	res, err := r.client.AddMember(ctx, plan.Username.ValueString(), r.roleAliases.canonical(plan.Role))
 	if err != nil {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("Failed to update member %s: %s", plan.Username.ValueString(), err.Error()))
+		resp.Diagnostics.Append(apiErrorDiagnostic(ctx, r.client, fmt.Sprintf("Failed to update member %s", plan.Username.ValueString()), err))
 		return
 	}
 
 	plan.Username = types.StringValue(res.Username)
-	plan.Role = types.StringValue(res.Role)
+	plan.Role = r.roleAliases.stateValue(plan.Role, res.Role)
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(r.setIdentity(ctx, resp.Identity, plan.Username.ValueString())...)
 }
 
 func (r *BlogMemberResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	defer appendStaleAPIKeyWarning(&resp.Diagnostics, r.client, r.warnings)
+	defer appendClockOffsetWarning(&resp.Diagnostics, r.client, r.warnings)
+
 	var state memberResourceModel
 
 	diags := req.State.Get(ctx, &state)
@@ -145,17 +414,436 @@ func (r *BlogMemberResource) Delete(ctx context.Context, req resource.DeleteRequ
 		return
 	}
 
-	tflog.Info(ctx, fmt.Sprintf("Deleting member %s", state.Username.ValueString()))
+	timeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := withOperationTimeout(ctx, "delete", timeout)
+	defer cancel()
 
-	err := r.client.DeleteMember(state.Username.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("Failed to remove member %s: %s", state.Username.ValueString(), err.Error()))
+	if state.DeletionProtection.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("deletion_protection"),
+			"Deletion protection is enabled",
+			fmt.Sprintf("Cannot destroy member %s because deletion_protection is enabled. Set deletion_protection to false and apply it before destroying this resource.", state.Username.ValueString()),
+		)
 		return
 	}
 
+	// abandon はブログを変更せずに state から外すだけなので、メンバー一覧を確認しない
+	if state.OnDestroy.ValueString() != onDestroyAbandon {
+		resp.Diagnostics.Append(r.checkRoster(ctx, req.Private)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	switch state.OnDestroy.ValueString() {
+	case onDestroyDemote:
+		if state.Expired.ValueBool() {
+			// 期限切れによって既に降格されているか、ブログのメンバーではない
+			break
+		}
+
+		tflog.Info(ctx, fmt.Sprintf("Demoting member %s to contributor", state.Username.ValueString()))
+
+		_, err := r.client.AddMember(ctx, state.Username.ValueString(), roleContributor)
+		if err != nil {
+			resp.Diagnostics.Append(apiErrorDiagnostic(ctx, r.client, fmt.Sprintf("Failed to demote member %s", state.Username.ValueString()), err))
+			return
+		}
+	case onDestroyAbandon:
+		tflog.Info(ctx, fmt.Sprintf("Abandoning member %s", state.Username.ValueString()))
+	default:
+		if state.Expired.ValueBool() {
+			// 期限切れによって既にブログから削除されている
+			break
+		}
+
+		tflog.Info(ctx, fmt.Sprintf("Deleting member %s", state.Username.ValueString()))
+
+		err := r.client.DeleteMember(ctx, state.Username.ValueString())
+		if err != nil {
+			resp.Diagnostics.Append(apiErrorDiagnostic(ctx, r.client, fmt.Sprintf("Failed to remove member %s", state.Username.ValueString()), err))
+			return
+		}
+	}
+
 	resp.State.RemoveResource(ctx)
 }
 
+func (r *BlogMemberResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		// resource is being destroyed
+		// refreshせずにplanしたときは、private stateに以前のapplyより前のメンバー一覧が記録されたままになっている
+		// 削除時はplanの記録と比較されるので、plan時のメンバー一覧を記録し直しておく
+		// abandon は削除時にメンバー一覧を確認しないので、記録しない
+		var onDestroy types.String
+		diags := req.State.GetAttribute(ctx, path.Root("on_destroy"), &onDestroy)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() || onDestroy.ValueString() == onDestroyAbandon {
+			return
+		}
+		resp.Diagnostics.Append(r.recordPlannedRoster(ctx, req.State, resp.Private)...)
+		return
+	}
+
+	var plan memberResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// 設定の検証時に unknown だった値を検証する
+	if !plan.Role.IsUnknown() {
+		resp.Diagnostics.Append(r.validateRole(plan.Role)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if r.policy != nil && !plan.Username.IsUnknown() && !plan.Role.IsUnknown() {
+		// plan時のメンバー一覧の取得は read のタイムアウトに従う
+		timeout, diags := plan.Timeouts.Read(ctx, defaultReadTimeout)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		ctx, cancel := withOperationTimeout(ctx, "read", timeout)
+		defer cancel()
+
+		resp.Diagnostics.Append(r.checkPolicy(ctx, &plan)...)
+		appendStaleAPIKeyWarning(&resp.Diagnostics, r.client, r.warnings)
+		appendClockOffsetWarning(&resp.Diagnostics, r.client, r.warnings)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if plan.Username.IsUnknown() {
+		plan.ID = types.StringUnknown()
+	} else {
+		plan.ID = types.StringValue(memberID(r.client.Owner(), r.client.BlogHost(), plan.Username.ValueString()))
+	}
+
+	// deletion_protection が指定されていないときはプロバイダの設定をデフォルトにする
+	var deletionProtection types.Bool
+	diags = req.Config.GetAttribute(ctx, path.Root("deletion_protection"), &deletionProtection)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if deletionProtection.IsNull() {
+		plan.DeletionProtection = types.BoolValue(r.defaultDeletionProtection)
+	}
+
+	if plan.ExpiresAt.IsUnknown() {
+		plan.Expired = types.BoolUnknown()
+	} else {
+		// Terraform は apply の直前にも plan し直すので、plan と apply の間に期限を過ぎると
+		// expired の値が変わり "Provider produced inconsistent final plan" で apply が失敗する
+		// その場合はもう一度 plan して apply すれば、期限切れの処理が適用される
+		expiresAt, ok := parseExpiresAt(plan.ExpiresAt)
+		expired := ok && !timeNow().Before(expiresAt)
+		plan.Expired = types.BoolValue(expired)
+
+		var state memberResourceModel
+		if !req.State.Raw.IsNull() {
+			diags = req.State.Get(ctx, &state)
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+
+		switch {
+		case expired && req.State.Raw.IsNull():
+			// 作成時に既に期限切れのときは、メンバーを追加しない
+			resp.Diagnostics.AddWarning(
+				"Membership has already expired",
+				fmt.Sprintf("The membership of %s expired at %s, so the member will not be added to the blog.", plan.Username.ValueString(), expiresAt.Format(time.RFC3339)),
+			)
+		case expired && !state.Expired.ValueBool():
+			// 期限切れの処理が未適用のとき
+			// ブログからの削除は destroy と同じように deletion_protection で防ぐ。降格はメンバーを残すので妨げない
+			if expiryAction(&plan) == onDestroyRemove && plan.DeletionProtection.ValueBool() {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("expires_at"),
+					"Deletion protection is enabled",
+					fmt.Sprintf("The membership of %s expired at %s, but it cannot be removed from the blog because deletion_protection is enabled. Set deletion_protection to false, set on_destroy to 'demote', or extend expires_at.", plan.Username.ValueString(), expiresAt.Format(time.RFC3339)),
+				)
+				return
+			}
+
+			action := "removed from the blog"
+			if expiryAction(&plan) == onDestroyDemote {
+				action = "downgraded to 'contributor'"
+			}
+			resp.Diagnostics.AddWarning(
+				"Membership has expired",
+				fmt.Sprintf("The membership of %s expired at %s and will be %s.", plan.Username.ValueString(), expiresAt.Format(time.RFC3339), action),
+			)
+		}
+	}
+
+	diags = resp.Plan.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// ImportState は次のいずれかの形式のIDでメンバーをインポートする
+//
+//	<username>
+//	<blog_host>:<username>
+//	<owner>/<blog_host>/<username>
+//
+// owner や blog_host を含むときは、プロバイダに設定されたブログと一致している必要がある
 func (r *BlogMemberResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("username"), req, resp)
+	var owner, blogHost, username string
+	if req.ID != "" {
+		var err error
+		owner, blogHost, username, err = parseImportID(req.ID)
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid import ID", err.Error())
+			return
+		}
+	} else {
+		// import ブロックで identity が指定されたとき
+		var identity memberIdentityModel
+		diags := req.Identity.Get(ctx, &identity)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		owner, blogHost, username = identity.Owner.ValueString(), identity.BlogHost.ValueString(), identity.Username.ValueString()
+	}
+
+	if owner != "" && owner != r.client.Owner() {
+		resp.Diagnostics.AddError(
+			"Invalid import ID",
+			fmt.Sprintf("The owner %q in the import ID does not match the owner %q configured in the provider.", owner, r.client.Owner()),
+		)
+	}
+	if blogHost != "" && blogHost != r.client.BlogHost() {
+		resp.Diagnostics.AddError(
+			"Invalid import ID",
+			fmt.Sprintf("The blog_host %q in the import ID does not match the blog_host %q configured in the provider.", blogHost, r.client.BlogHost()),
+		)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// role はこの後のReadで設定される
+	state := newMemberResourceModel(r.client, username, "", r.defaultDeletionProtection)
+	state.Role = newRoleNull()
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(r.setIdentity(ctx, resp.Identity, username)...)
+}
+
+func (r *BlogMemberResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {
+			// v0 は v0.1.x までの username と role のみのスキーマ
+			// それ以降に追加された属性も含まれている場合があるので、PriorSchema は使わずにJSONを直接読む
+			StateUpgrader: r.upgradeStateV0,
+		},
+	}
+}
+
+func (r *BlogMemberResource) upgradeStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	if req.RawState == nil || req.RawState.JSON == nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Resource State", "The prior state of the member resource is missing or not in JSON format.")
+		return
+	}
+
+	var prior struct {
+		Username           string  `json:"username"`
+		Role               string  `json:"role"`
+		OnDestroy          *string `json:"on_destroy"`
+		ExpiresAt          *string `json:"expires_at"`
+		Expired            *bool   `json:"expired"`
+		DeletionProtection *bool   `json:"deletion_protection"`
+		Reason             *string `json:"reason"`
+	}
+	if err := json.Unmarshal(req.RawState.JSON, &prior); err != nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Resource State", fmt.Sprintf("Failed to parse the prior state of the member resource: %s", err))
+		return
+	}
+
+	state := memberResourceModel{
+		ID:                 types.StringNull(),
+		Username:           types.StringValue(prior.Username),
+		Role:               newRoleValue(prior.Role),
+		OnDestroy:          types.StringValue(onDestroyRemove),
+		ExpiresAt:          types.StringPointerValue(prior.ExpiresAt),
+		Expired:            types.BoolValue(false),
+		DeletionProtection: types.BoolValue(r.defaultDeletionProtection),
+		Reason:             types.StringPointerValue(prior.Reason),
+		Timeouts:           nullTimeouts(),
+	}
+	if prior.OnDestroy != nil {
+		state.OnDestroy = types.StringValue(*prior.OnDestroy)
+	}
+	if prior.Expired != nil {
+		state.Expired = types.BoolValue(*prior.Expired)
+	}
+	if prior.DeletionProtection != nil {
+		state.DeletionProtection = types.BoolValue(*prior.DeletionProtection)
+	}
+	// プロバイダが設定される前に呼ばれたときは、次のReadでIDが設定される
+	if r.client != nil {
+		state.ID = types.StringValue(memberID(r.client.Owner(), r.client.BlogHost(), prior.Username))
+	}
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// newMemberResourceModel はTerraformの管理下にないメンバーを表すモデルを返す
+// インポート直後に差分が出ないように、role 以外の属性にはデフォルト値を入れておく
+func newMemberResourceModel(c *client.Client, username, role string, deletionProtection bool) memberResourceModel {
+	return memberResourceModel{
+		ID:                 types.StringValue(memberID(c.Owner(), c.BlogHost(), username)),
+		Username:           types.StringValue(username),
+		Role:               newRoleValue(role),
+		OnDestroy:          types.StringValue(onDestroyRemove),
+		ExpiresAt:          types.StringNull(),
+		Expired:            types.BoolValue(false),
+		DeletionProtection: types.BoolValue(deletionProtection),
+		Reason:             types.StringNull(),
+		Timeouts:           nullTimeouts(),
+	}
+}
+
+// memberID はメンバーのIDを返す
+// IDは <owner>/<blog_host>/<username> の形式で、インポートIDとしても使える
+func memberID(owner, blogHost, username string) string {
+	return fmt.Sprintf("%s/%s/%s", owner, blogHost, username)
+}
+
+// setIdentity はメンバーのリソースアイデンティティを設定する
+func (r *BlogMemberResource) setIdentity(ctx context.Context, identity *tfsdk.ResourceIdentity, username string) diag.Diagnostics {
+	if identity == nil {
+		return nil
+	}
+	return identity.Set(ctx, &memberIdentityModel{
+		Owner:    types.StringValue(r.client.Owner()),
+		BlogHost: types.StringValue(r.client.BlogHost()),
+		Username: types.StringValue(username),
+	})
+}
+
+// checkPolicy は現在のメンバー一覧に対してメンバーの計画がポリシーに違反していないかを検証する
+func (r *BlogMemberResource) checkPolicy(ctx context.Context, m *memberResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	members, err := r.client.ListMembers(ctx)
+	if err != nil {
+		diags.Append(apiErrorDiagnostic(ctx, r.client, "Failed to list members", err))
+		return diags
+	}
+	diags.Append(r.policy.check(m.Username.ValueString(), r.roleAliases.canonical(m.Role), m.Reason, members)...)
+	return diags
+}
+
+// validateRole はロールがAPIでの値、日本語の名前、プロバイダの role_aliases の別名のいずれかであることを検証する
+func (r *BlogMemberResource) validateRole(role roleValue) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if _, ok := r.roleAliases.normalize(role.ValueString()); !ok {
+		diags.AddAttributeError(
+			path.Root("role"),
+			"Invalid role",
+			fmt.Sprintf("Role must be one of %s, their Japanese labels, or an alias defined in the provider's 'role_aliases', got: %q", roleNamesDescription(), role.ValueString()),
+		)
+	}
+	return diags
+}
+
+// enforceExpiry は期限切れのメンバーをブログから削除するか、寄稿者に降格する
+func (r *BlogMemberResource) enforceExpiry(ctx context.Context, m *memberResourceModel) error {
+	username := m.Username.ValueString()
+
+	members, err := r.client.ListMembers(ctx)
+	if err != nil {
+		return err
+	}
+	var found *client.BlogMember
+	for _, member := range members {
+		if member.Username == username {
+			found = member
+			break
+		}
+	}
+	// ブログのメンバーでなければ、降格するとかえって権限を与えてしまう
+	if expiryEnforced(m, found) {
+		return nil
+	}
+
+	if expiryAction(m) == onDestroyDemote {
+		tflog.Info(ctx, fmt.Sprintf("Membership of %s has expired, demoting to contributor", username))
+		_, err := r.client.AddMember(ctx, username, roleContributor)
+		return err
+	}
+	tflog.Info(ctx, fmt.Sprintf("Membership of %s has expired, deleting", username))
+	return r.client.DeleteMember(ctx, username)
+}
+
+// expiryAction は期限切れのときに行う処理を返す
+// on_destroy が demote のときは降格し、それ以外のときはブログから削除する
+func expiryAction(m *memberResourceModel) string {
+	if m.OnDestroy.ValueString() == onDestroyDemote {
+		return onDestroyDemote
+	}
+	return onDestroyRemove
+}
+
+// expiryEnforced は期限切れの処理がブログのメンバーに適用されたままであるかを返す
+// 降格するときも、ブログのメンバーでなければ適用済みとみなす
+func expiryEnforced(m *memberResourceModel, member *client.BlogMember) bool {
+	if member == nil {
+		return true
+	}
+	return expiryAction(m) == onDestroyDemote && member.Role == roleContributor
+}
+
+// parseExpiresAt は expires_at の値をパースする
+// 値が指定されていないときは false を返す
+func parseExpiresAt(v types.String) (time.Time, bool) {
+	if v.IsNull() || v.IsUnknown() {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v.ValueString())
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// parseImportID はインポートIDを owner, blog_host, username に分解する
+// IDに含まれない部分は空文字列になる
+func parseImportID(id string) (owner, blogHost, username string, err error) {
+	const format = "The import ID must be one of '<username>', '<blog_host>:<username>' or '<owner>/<blog_host>/<username>'"
+
+	if parts := strings.Split(id, "/"); len(parts) > 1 {
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return "", "", "", fmt.Errorf("%s, got: %q", format, id)
+		}
+		owner, blogHost, username = parts[0], parts[1], parts[2]
+	} else if parts := strings.Split(id, ":"); len(parts) > 1 {
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", "", "", fmt.Errorf("%s, got: %q", format, id)
+		}
+		blogHost, username = parts[0], parts[1]
+	} else {
+		username = id
+	}
+
+	if username == "" {
+		return "", "", "", fmt.Errorf("%s, got: %q", format, id)
+	}
+	return owner, blogHost, username, nil
 }
@@ -1,14 +1,41 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client/recorder"
 )
 
 func TestBlogMember(t *testing.T) {
+	// カセットを記録してあれば、APIのレスポンスを再生してAPIにアクセスせずに実行する
+	// カセットがないときや HATENABLOG_CASSETTE_MODE=live のときはAPIにアクセスし、
+	// HATENABLOG_CASSETTE_MODE=record のときはAPIにアクセスして記録し直す
+	rec := recorder.ForTest(t, filepath.Join("testdata", "cassettes", "TestBlogMember.yaml"), os.Getenv("TF_VAR_HATENABLOG_APIKEY"))
+	if rec.Mode() == recorder.ModeReplay {
+		// 再生するときはAPIにアクセスしないので、受け入れテストとしてではなく常に実行する
+		t.Setenv("TF_ACC", "1")
+		t.Setenv("TF_VAR_HATENABLOG_APIKEY", "apikey")
+	}
+
 	resource.Test(t, resource.TestCase{
-		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ProtoV6ProviderFactories: protoV6ProviderFactoriesWithClientOptions(client.WithTransport(rec)),
 		Steps: []resource.TestStep{
 			{
 				Config: providerConfig + `
@@ -20,6 +47,7 @@ func TestBlogMember(t *testing.T) {
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("hatenablog-members_member.tf-test2", "username", "hatenablog-tf-test2"),
 					resource.TestCheckResourceAttr("hatenablog-members_member.tf-test2", "role", "admin"),
+					resource.TestCheckResourceAttr("hatenablog-members_member.tf-test2", "on_destroy", "remove"),
 				),
 			},
 			{
@@ -40,3 +68,963 @@ func TestBlogMember(t *testing.T) {
 		},
 	})
 }
+
+func TestParseImportID(t *testing.T) {
+	tests := []struct {
+		id                        string
+		owner, blogHost, username string
+		wantErr                   bool
+	}{
+		{id: "member", username: "member"},
+		{id: "blog.example.com:member", blogHost: "blog.example.com", username: "member"},
+		{id: "owner/blog.example.com/member", owner: "owner", blogHost: "blog.example.com", username: "member"},
+		{id: "", wantErr: true},
+		{id: "blog.example.com:", wantErr: true},
+		{id: ":member", wantErr: true},
+		{id: "a:b:c", wantErr: true},
+		{id: "blog.example.com/member", wantErr: true},
+		{id: "owner//member", wantErr: true},
+		{id: "owner/blog.example.com/member/extra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			owner, blogHost, username, err := parseImportID(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if owner != tt.owner || blogHost != tt.blogHost || username != tt.username {
+				t.Errorf("unexpected result: owner=%q, blog_host=%q, username=%q", owner, blogHost, username)
+			}
+		})
+	}
+}
+
+func TestBlogMemberResource_UpgradeStateV0(t *testing.T) {
+	ctx := context.Background()
+	c := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+
+	var schemaResp fwresource.SchemaResponse
+	(&BlogMemberResource{}).Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+
+	tests := []struct {
+		name    string
+		fixture string
+		client  *client.Client
+		want    memberResourceModel
+	}{
+		{
+			name:    "v0",
+			fixture: "member_state_v0.json",
+			client:  c,
+			want: memberResourceModel{
+				ID:                 types.StringValue("owner/blog.example.com/member"),
+				Username:           types.StringValue("member"),
+				Role:               newRoleValue("editor"),
+				OnDestroy:          types.StringValue(onDestroyRemove),
+				ExpiresAt:          types.StringNull(),
+				Expired:            types.BoolValue(false),
+				DeletionProtection: types.BoolValue(false),
+				Reason:             types.StringNull(),
+				Timeouts:           nullTimeouts(),
+			},
+		},
+		{
+			name:    "v0 without configured client",
+			fixture: "member_state_v0.json",
+			client:  nil,
+			want: memberResourceModel{
+				ID:                 types.StringNull(),
+				Username:           types.StringValue("member"),
+				Role:               newRoleValue("editor"),
+				OnDestroy:          types.StringValue(onDestroyRemove),
+				ExpiresAt:          types.StringNull(),
+				Expired:            types.BoolValue(false),
+				DeletionProtection: types.BoolValue(false),
+				Reason:             types.StringNull(),
+				Timeouts:           nullTimeouts(),
+			},
+		},
+		{
+			name:    "v0 with options",
+			fixture: "member_state_v0_with_options.json",
+			client:  c,
+			want: memberResourceModel{
+				ID:                 types.StringValue("owner/blog.example.com/member"),
+				Username:           types.StringValue("member"),
+				Role:               newRoleValue("contributor"),
+				OnDestroy:          types.StringValue(onDestroyDemote),
+				ExpiresAt:          types.StringValue("2024-01-01T00:00:00Z"),
+				Expired:            types.BoolValue(true),
+				DeletionProtection: types.BoolValue(true),
+				Reason:             types.StringValue("campaign"),
+				Timeouts:           nullTimeouts(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &BlogMemberResource{client: tt.client}
+			upgrader, ok := r.UpgradeState(ctx)[0]
+			if !ok {
+				t.Fatal("no state upgrader for version 0")
+			}
+
+			req := fwresource.UpgradeStateRequest{RawState: &tfprotov6.RawState{JSON: raw}}
+			resp := fwresource.UpgradeStateResponse{
+				State: tfsdk.State{
+					Schema: schemaResp.Schema,
+					Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+				},
+			}
+			upgrader.StateUpgrader(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			var got memberResourceModel
+			if diags := resp.State.Get(ctx, &got); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected state: got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeMemberAPI はメンバーのAPIを模したテスト用のサーバー
+type fakeMemberAPI struct {
+	mu       sync.Mutex
+	members  []*client.BlogMember
+	requests []string
+}
+
+// newFakeMemberAPI は members がいるブログのAPIを起動し、それに接続するクライアントを返す
+func newFakeMemberAPI(t *testing.T, members ...*client.BlogMember) (*fakeMemberAPI, *client.Client) {
+	t.Helper()
+
+	api := &fakeMemberAPI{members: members}
+	server := httptest.NewServer(http.HandlerFunc(api.serveHTTP))
+	t.Cleanup(server.Close)
+
+	c := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+	if err := c.SetEndpoint(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	return api, c
+}
+
+func (api *fakeMemberAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	api.requests = append(api.requests, r.Method+" "+r.URL.Path)
+
+	const prefix = "/owner/blog.example.com/api/members"
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == prefix:
+		json.NewEncoder(w).Encode(map[string]interface{}{"members": api.members})
+	case r.Method == http.MethodPost && r.URL.Path == prefix:
+		var member client.BlogMember
+		if err := json.NewDecoder(r.Body).Decode(&member); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		api.remove(member.Username)
+		api.members = append(api.members, &member)
+		json.NewEncoder(w).Encode(&member)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, prefix+"/"):
+		if !api.remove(strings.TrimPrefix(r.URL.Path, prefix+"/")) {
+			http.NotFound(w, r)
+		}
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+// remove はメンバーを削除し、メンバーがいたかどうかを返す
+func (api *fakeMemberAPI) remove(username string) bool {
+	for i, member := range api.members {
+		if member.Username == username {
+			api.members = append(api.members[:i], api.members[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// role はメンバーのロールを返す。メンバーがいないときは空文字列を返す
+func (api *fakeMemberAPI) role(username string) string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	for _, member := range api.members {
+		if member.Username == username {
+			return member.Role
+		}
+	}
+	return ""
+}
+
+// modifications はメンバーを変更したリクエストを返す
+func (api *fakeMemberAPI) modifications() []string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	var requests []string
+	for _, req := range api.requests {
+		if !strings.HasPrefix(req, http.MethodGet+" ") {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// testMemberModel はテストで使うリソースのモデルを返す
+func testMemberModel(username, role string) memberResourceModel {
+	return memberResourceModel{
+		ID:                 types.StringValue(memberID("owner", "blog.example.com", username)),
+		Username:           types.StringValue(username),
+		Role:               newRoleValue(role),
+		OnDestroy:          types.StringValue(onDestroyRemove),
+		ExpiresAt:          types.StringNull(),
+		Expired:            types.BoolValue(false),
+		DeletionProtection: types.BoolValue(false),
+		Reason:             types.StringNull(),
+		Timeouts:           nullTimeouts(),
+	}
+}
+
+// newMemberState はモデルの値を持つリソースのstateを返す
+func newMemberState(t *testing.T, m memberResourceModel) tfsdk.State {
+	t.Helper()
+
+	ctx := context.Background()
+	var schemaResp fwresource.SchemaResponse
+	(&BlogMemberResource{}).Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if diags := state.Set(ctx, &m); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	return state
+}
+
+func TestBlogMemberResource_Delete_OnDestroy(t *testing.T) {
+	tests := map[string]struct {
+		onDestroy         string
+		wantRole          string
+		wantModifications []string
+	}{
+		"remove": {
+			onDestroy:         onDestroyRemove,
+			wantRole:          "",
+			wantModifications: []string{"DELETE /owner/blog.example.com/api/members/member"},
+		},
+		"demote": {
+			onDestroy:         onDestroyDemote,
+			wantRole:          roleContributor,
+			wantModifications: []string{"POST /owner/blog.example.com/api/members"},
+		},
+		"abandon": {
+			onDestroy:         onDestroyAbandon,
+			wantRole:          "admin",
+			wantModifications: nil,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			api, c := newFakeMemberAPI(t, &client.BlogMember{Username: "member", Role: "admin"})
+			r := &BlogMemberResource{client: c}
+
+			m := testMemberModel("member", "admin")
+			m.OnDestroy = types.StringValue(tt.onDestroy)
+			state := newMemberState(t, m)
+
+			req := fwresource.DeleteRequest{State: state}
+			resp := fwresource.DeleteResponse{State: state}
+			r.Delete(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			if !resp.State.Raw.IsNull() {
+				t.Errorf("resource should be removed from the state: %v", resp.State.Raw)
+			}
+			if got := api.role("member"); got != tt.wantRole {
+				t.Errorf("unexpected role of the member: %q, want %q", got, tt.wantRole)
+			}
+			if got := api.modifications(); !reflect.DeepEqual(got, tt.wantModifications) {
+				t.Errorf("unexpected requests: %v, want %v", got, tt.wantModifications)
+			}
+			// abandon は state から外すだけなので、メンバー一覧も取得しない
+			if tt.onDestroy == onDestroyAbandon && len(api.requests) != 0 {
+				t.Errorf("unexpected requests: %v", api.requests)
+			}
+		})
+	}
+}
+
+func TestBlogMemberResource_ModifyPlan_DestroyAbandon(t *testing.T) {
+	ctx := context.Background()
+	api, c := newFakeMemberAPI(t, &client.BlogMember{Username: "member", Role: "admin"})
+	r := &BlogMemberResource{client: c}
+
+	m := testMemberModel("member", "admin")
+	m.OnDestroy = types.StringValue(onDestroyAbandon)
+	state := newMemberState(t, m)
+
+	// abandon は削除時にメンバー一覧を確認しないので、plan 時にも記録しない
+	req := fwresource.ModifyPlanRequest{
+		Plan:  tfsdk.Plan{Schema: state.Schema, Raw: tftypes.NewValue(state.Schema.Type().TerraformType(ctx), nil)},
+		State: state,
+	}
+	resp := fwresource.ModifyPlanResponse{Plan: req.Plan}
+	r.ModifyPlan(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+	if len(api.requests) != 0 {
+		t.Errorf("unexpected requests: %v", api.requests)
+	}
+}
+
+// setTimeNow はテストの間 timeNow が now を返すようにする
+func setTimeNow(t *testing.T, now time.Time) {
+	t.Helper()
+
+	orig := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = orig })
+}
+
+// testMemberConfig はモデルの設定できる値だけを持つ設定を返す
+func testMemberConfig(t *testing.T, m memberResourceModel) tfsdk.Config {
+	t.Helper()
+
+	m.ID = types.StringNull()
+	m.Expired = types.BoolNull()
+	state := newMemberState(t, m)
+	return tfsdk.Config{Schema: state.Schema, Raw: state.Raw}
+}
+
+func TestBlogMemberResource_ModifyPlan_Expired(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	setTimeNow(t, now)
+
+	tests := map[string]struct {
+		expiresAt    types.String
+		stateExpired *bool
+		want         bool
+		wantWarning  bool
+	}{
+		"without expires_at": {
+			expiresAt: types.StringNull(),
+			want:      false,
+		},
+		"before expiry": {
+			expiresAt: types.StringValue(now.Add(time.Second).Format(time.RFC3339)),
+			want:      false,
+		},
+		"at expiry": {
+			expiresAt:   types.StringValue(now.Format(time.RFC3339)),
+			want:        true,
+			wantWarning: true,
+		},
+		"create after expiry": {
+			expiresAt:   types.StringValue(now.Add(-time.Hour).Format(time.RFC3339)),
+			want:        true,
+			wantWarning: true,
+		},
+		"update after expiry": {
+			expiresAt:    types.StringValue(now.Add(-time.Hour).Format(time.RFC3339)),
+			stateExpired: new(bool),
+			want:         true,
+			wantWarning:  true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+			r := &BlogMemberResource{client: c}
+
+			m := testMemberModel("member", "editor")
+			m.ExpiresAt = tt.expiresAt
+			config := testMemberConfig(t, m)
+			m.Expired = types.BoolUnknown()
+			plan := newMemberState(t, m)
+
+			state := tfsdk.State{Schema: plan.Schema, Raw: tftypes.NewValue(plan.Schema.Type().TerraformType(ctx), nil)}
+			if tt.stateExpired != nil {
+				sm := testMemberModel("member", "editor")
+				sm.Expired = types.BoolValue(*tt.stateExpired)
+				state = newMemberState(t, sm)
+			}
+
+			req := fwresource.ModifyPlanRequest{
+				Config: config,
+				Plan:   tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw},
+				State:  state,
+			}
+			resp := fwresource.ModifyPlanResponse{Plan: req.Plan}
+			r.ModifyPlan(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			var got memberResourceModel
+			if diags := resp.Plan.Get(ctx, &got); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if got.Expired.ValueBool() != tt.want {
+				t.Errorf("unexpected expired: %v, want %v", got.Expired, tt.want)
+			}
+			if got := resp.Diagnostics.WarningsCount() > 0; got != tt.wantWarning {
+				t.Errorf("unexpected warnings: %v", resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestBlogMemberResource_Update_EnforceExpiry(t *testing.T) {
+	editor := &client.BlogMember{Username: "member", Role: "editor"}
+	tests := map[string]struct {
+		onDestroy         string
+		members           []*client.BlogMember
+		wantRole          string
+		wantModifications []string
+	}{
+		"remove": {
+			onDestroy:         onDestroyRemove,
+			members:           []*client.BlogMember{editor},
+			wantRole:          "",
+			wantModifications: []string{"DELETE /owner/blog.example.com/api/members/member"},
+		},
+		"demote": {
+			onDestroy:         onDestroyDemote,
+			members:           []*client.BlogMember{editor},
+			wantRole:          roleContributor,
+			wantModifications: []string{"POST /owner/blog.example.com/api/members"},
+		},
+		// ブログのメンバーでなければ、降格して権限を与えない
+		"demote non-member": {
+			onDestroy: onDestroyDemote,
+			wantRole:  "",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			api, c := newFakeMemberAPI(t, tt.members...)
+			// private state はフレームワークの外では作れないので、メンバー一覧の確認はしない
+			r := &BlogMemberResource{client: c, skipRosterCheck: true}
+
+			m := testMemberModel("member", "editor")
+			m.OnDestroy = types.StringValue(tt.onDestroy)
+			state := newMemberState(t, m)
+			m.ExpiresAt = types.StringValue("2024-01-01T00:00:00Z")
+			m.Expired = types.BoolValue(true)
+			plan := newMemberState(t, m)
+
+			req := fwresource.UpdateRequest{
+				Plan:  tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw},
+				State: state,
+			}
+			resp := fwresource.UpdateResponse{State: state}
+			r.Update(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			var got memberResourceModel
+			if diags := resp.State.Get(ctx, &got); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if !got.Expired.ValueBool() || got.Role.ValueString() != "editor" {
+				t.Errorf("expired member should stay in the state as configured: %+v", got)
+			}
+			if got := api.role("member"); got != tt.wantRole {
+				t.Errorf("unexpected role of the member: %q, want %q", got, tt.wantRole)
+			}
+			if got := api.modifications(); !reflect.DeepEqual(got, tt.wantModifications) {
+				t.Errorf("unexpected requests: %v, want %v", got, tt.wantModifications)
+			}
+		})
+	}
+}
+
+func TestBlogMemberResource_Read_ExpiryWarning(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	setTimeNow(t, now)
+
+	tests := map[string]struct {
+		expiresAt   types.String
+		wantWarning bool
+	}{
+		"without expires_at": {
+			expiresAt: types.StringNull(),
+		},
+		"long before expiry": {
+			expiresAt: types.StringValue(now.Add(expiryWarningPeriod + time.Hour).Format(time.RFC3339)),
+		},
+		"soon before expiry": {
+			expiresAt:   types.StringValue(now.Add(expiryWarningPeriod - time.Hour).Format(time.RFC3339)),
+			wantWarning: true,
+		},
+		"after expiry": {
+			expiresAt: types.StringValue(now.Add(-time.Hour).Format(time.RFC3339)),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, c := newFakeMemberAPI(t, &client.BlogMember{Username: "member", Role: "editor"})
+			// private state はフレームワークの外では作れないので、メンバー一覧の確認はしない
+			r := &BlogMemberResource{client: c, skipRosterCheck: true}
+
+			m := testMemberModel("member", "editor")
+			m.ExpiresAt = tt.expiresAt
+			state := newMemberState(t, m)
+
+			req := fwresource.ReadRequest{State: state}
+			resp := fwresource.ReadResponse{State: state}
+			r.Read(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			if got := resp.Diagnostics.WarningsCount() > 0; got != tt.wantWarning {
+				t.Errorf("unexpected warnings: %v", resp.Diagnostics)
+			}
+		})
+	}
+}
+
+// modifyMemberPlan は設定 config と state から作ったplanで ModifyPlan を呼び出す
+// state が nil のときは新規作成のplanになる
+func modifyMemberPlan(t *testing.T, r *BlogMemberResource, config memberResourceModel, state *memberResourceModel) fwresource.ModifyPlanResponse {
+	t.Helper()
+
+	ctx := context.Background()
+	req := fwresource.ModifyPlanRequest{Config: testMemberConfig(t, config)}
+
+	// 設定されていない computed の属性はフレームワークによって unknown になる
+	plan := config
+	plan.ID = types.StringUnknown()
+	plan.Expired = types.BoolUnknown()
+	if plan.DeletionProtection.IsNull() {
+		plan.DeletionProtection = types.BoolUnknown()
+	}
+	planState := newMemberState(t, plan)
+	req.Plan = tfsdk.Plan{Schema: planState.Schema, Raw: planState.Raw}
+
+	if state != nil {
+		req.State = newMemberState(t, *state)
+	} else {
+		req.State = tfsdk.State{Schema: planState.Schema, Raw: tftypes.NewValue(planState.Schema.Type().TerraformType(ctx), nil)}
+	}
+
+	resp := fwresource.ModifyPlanResponse{Plan: req.Plan}
+	r.ModifyPlan(ctx, req, &resp)
+	return resp
+}
+
+func TestBlogMemberResource_Delete_DeletionProtection(t *testing.T) {
+	ctx := context.Background()
+	api, c := newFakeMemberAPI(t, &client.BlogMember{Username: "member", Role: "admin"})
+	r := &BlogMemberResource{client: c}
+
+	m := testMemberModel("member", "admin")
+	m.DeletionProtection = types.BoolValue(true)
+	state := newMemberState(t, m)
+
+	req := fwresource.DeleteRequest{State: state}
+	resp := fwresource.DeleteResponse{State: state}
+	r.Delete(ctx, req, &resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("destroy should fail while deletion_protection is enabled")
+	}
+
+	if resp.State.Raw.IsNull() {
+		t.Error("resource should be kept in the state")
+	}
+	if got := api.role("member"); got != "admin" {
+		t.Errorf("member should be kept on the blog: role %q", got)
+	}
+	// メンバー一覧を確認する前に失敗する
+	if len(api.requests) != 0 {
+		t.Errorf("unexpected requests: %v", api.requests)
+	}
+}
+
+func TestBlogMemberResource_ModifyPlan_DeletionProtection(t *testing.T) {
+	tests := map[string]struct {
+		providerDefault bool
+		configured      types.Bool
+		want            bool
+	}{
+		"provider default false": {
+			providerDefault: false,
+			configured:      types.BoolNull(),
+			want:            false,
+		},
+		"provider default true": {
+			providerDefault: true,
+			configured:      types.BoolNull(),
+			want:            true,
+		},
+		"configured false overrides provider default": {
+			providerDefault: true,
+			configured:      types.BoolValue(false),
+			want:            false,
+		},
+		"configured true": {
+			providerDefault: false,
+			configured:      types.BoolValue(true),
+			want:            true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+			r := &BlogMemberResource{}
+			r.Configure(ctx, fwresource.ConfigureRequest{
+				ProviderData: &blogMemberProviderData{Client: c, DeletionProtection: tt.providerDefault},
+			}, &fwresource.ConfigureResponse{})
+
+			m := testMemberModel("member", "editor")
+			m.DeletionProtection = tt.configured
+			resp := modifyMemberPlan(t, r, m, nil)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			var got memberResourceModel
+			if diags := resp.Plan.Get(ctx, &got); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if got.DeletionProtection.ValueBool() != tt.want {
+				t.Errorf("unexpected deletion_protection: %v, want %v", got.DeletionProtection, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlogMemberResource_ModifyPlan_ExpiryWithDeletionProtection(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	setTimeNow(t, now)
+
+	tests := map[string]struct {
+		onDestroy    string
+		stateExpired bool
+		create       bool
+		wantErr      bool
+	}{
+		"remove": {
+			onDestroy: onDestroyRemove,
+			wantErr:   true,
+		},
+		"demote": {
+			onDestroy: onDestroyDemote,
+			wantErr:   false,
+		},
+		"already removed": {
+			onDestroy:    onDestroyRemove,
+			stateExpired: true,
+			wantErr:      false,
+		},
+		// 作成時はブログから削除するメンバーがいない
+		"create": {
+			onDestroy: onDestroyRemove,
+			create:    true,
+			wantErr:   false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+			r := &BlogMemberResource{client: c}
+
+			state := testMemberModel("member", "editor")
+			state.OnDestroy = types.StringValue(tt.onDestroy)
+			state.DeletionProtection = types.BoolValue(true)
+			state.Expired = types.BoolValue(tt.stateExpired)
+
+			config := state
+			config.ExpiresAt = types.StringValue(now.Add(-time.Hour).Format(time.RFC3339))
+			prior := &state
+			if tt.create {
+				prior = nil
+			}
+			resp := modifyMemberPlan(t, r, config, prior)
+			if got := resp.Diagnostics.HasError(); got != tt.wantErr {
+				t.Errorf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestBlogMemberResource_Create_Policy(t *testing.T) {
+	tests := map[string]struct {
+		members           []*client.BlogMember
+		wantErr           bool
+		wantModifications []string
+	}{
+		"allowed": {
+			members:           []*client.BlogMember{{Username: "other", Role: "editor"}},
+			wantModifications: []string{"POST /owner/blog.example.com/api/members"},
+		},
+		"admin added since plan": {
+			members: []*client.BlogMember{{Username: "other", Role: "admin"}},
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			api, c := newFakeMemberAPI(t, tt.members...)
+			r := &BlogMemberResource{client: c, policy: &membershipPolicy{maxAdmins: 1}}
+
+			plan := newMemberState(t, testMemberModel("member", "admin"))
+			req := fwresource.CreateRequest{Plan: tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw}}
+			resp := fwresource.CreateResponse{State: plan}
+			r.Create(ctx, req, &resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Errorf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			if got := api.modifications(); !reflect.DeepEqual(got, tt.wantModifications) {
+				t.Errorf("unexpected requests: %v, want %v", got, tt.wantModifications)
+			}
+		})
+	}
+}
+
+func TestBlogMemberResource_Create_Expired(t *testing.T) {
+	for _, onDestroy := range []string{onDestroyRemove, onDestroyDemote} {
+		t.Run(onDestroy, func(t *testing.T) {
+			ctx := context.Background()
+			api, c := newFakeMemberAPI(t)
+			r := &BlogMemberResource{client: c}
+
+			m := testMemberModel("member", "editor")
+			m.OnDestroy = types.StringValue(onDestroy)
+			m.ExpiresAt = types.StringValue("2024-01-01T00:00:00Z")
+			m.Expired = types.BoolValue(true)
+			plan := newMemberState(t, m)
+
+			// 既に期限切れのメンバーは追加せず、APIも呼ばない
+			req := fwresource.CreateRequest{Plan: tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw}}
+			resp := fwresource.CreateResponse{State: plan}
+			r.Create(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			var got memberResourceModel
+			if diags := resp.State.Get(ctx, &got); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if !got.Expired.ValueBool() {
+				t.Errorf("expired member should be in the state: %+v", got)
+			}
+			if len(api.requests) != 0 {
+				t.Errorf("unexpected requests: %v", api.requests)
+			}
+		})
+	}
+}
+
+// newMemberIdentity はメンバーのリソースアイデンティティを返す
+func newMemberIdentity(t *testing.T, m *memberIdentityModel) *tfsdk.ResourceIdentity {
+	t.Helper()
+
+	ctx := context.Background()
+	var identitySchemaResp fwresource.IdentitySchemaResponse
+	(&BlogMemberResource{}).IdentitySchema(ctx, fwresource.IdentitySchemaRequest{}, &identitySchemaResp)
+
+	identity := &tfsdk.ResourceIdentity{
+		Schema: identitySchemaResp.IdentitySchema,
+		Raw:    tftypes.NewValue(identitySchemaResp.IdentitySchema.Type().TerraformType(ctx), nil),
+	}
+	if m != nil {
+		if diags := identity.Set(ctx, m); diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+	}
+	return identity
+}
+
+func TestBlogMemberResource_ImportState_Identity(t *testing.T) {
+	tests := map[string]struct {
+		owner, blogHost string
+		wantErr         bool
+	}{
+		"matching":               {owner: "owner", blogHost: "blog.example.com"},
+		"different owner":        {owner: "someone", blogHost: "blog.example.com", wantErr: true},
+		"different blog_host":    {owner: "owner", blogHost: "other.example.com", wantErr: true},
+		"different owner & host": {owner: "someone", blogHost: "other.example.com", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+			r := &BlogMemberResource{client: c}
+
+			req := fwresource.ImportStateRequest{
+				Identity: newMemberIdentity(t, &memberIdentityModel{
+					Owner:    types.StringValue(tt.owner),
+					BlogHost: types.StringValue(tt.blogHost),
+					Username: types.StringValue("member"),
+				}),
+			}
+			state := newMemberState(t, testMemberModel("member", "editor"))
+			resp := fwresource.ImportStateResponse{
+				State:    tfsdk.State{Schema: state.Schema, Raw: tftypes.NewValue(state.Schema.Type().TerraformType(ctx), nil)},
+				Identity: newMemberIdentity(t, nil),
+			}
+			r.ImportState(ctx, req, &resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var got memberResourceModel
+			if diags := resp.State.Get(ctx, &got); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if got.ID.ValueString() != "owner/blog.example.com/member" || got.Username.ValueString() != "member" || !got.Role.IsNull() {
+				t.Errorf("unexpected state: %+v", got)
+			}
+
+			var identity memberIdentityModel
+			if diags := resp.Identity.Get(ctx, &identity); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if identity.Owner.ValueString() != "owner" || identity.BlogHost.ValueString() != "blog.example.com" || identity.Username.ValueString() != "member" {
+				t.Errorf("unexpected identity: %+v", identity)
+			}
+		})
+	}
+}
+
+func TestBlogMemberResource_Read_Role(t *testing.T) {
+	tests := map[string]struct {
+		prior roleValue
+		role  string
+		want  roleValue
+	}{
+		"label":    {prior: newRoleValue("管理者"), role: roleAdmin, want: newRoleValue("管理者")},
+		"alias":    {prior: newRoleValue("writer"), role: roleContributor, want: newRoleValue("writer")},
+		"changed":  {prior: newRoleValue("管理者"), role: roleEditor, want: newRoleValue(roleEditor)},
+		"imported": {prior: newRoleNull(), role: roleAdmin, want: newRoleValue(roleAdmin)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, c := newFakeMemberAPI(t, &client.BlogMember{Username: "member", Role: tt.role})
+			r := &BlogMemberResource{client: c, skipRosterCheck: true, roleAliases: roleAliases{"writer": roleContributor}}
+
+			m := testMemberModel("member", "")
+			m.Role = tt.prior
+			state := newMemberState(t, m)
+
+			req := fwresource.ReadRequest{State: state}
+			resp := fwresource.ReadResponse{State: state}
+			r.Read(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			var got memberResourceModel
+			if diags := resp.State.Get(ctx, &got); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if !got.Role.Equal(tt.want) {
+				t.Errorf("unexpected role: %s, want %s", got.Role, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlogMemberResource_Update_RoleLabelAfterImport(t *testing.T) {
+	ctx := context.Background()
+	api, c := newFakeMemberAPI(t, &client.BlogMember{Username: "member", Role: roleAdmin})
+	r := &BlogMemberResource{client: c, skipRosterCheck: true}
+
+	// インポートしたときはAPIでの値が保存され、設定の日本語の名前との差分になる
+	state := newMemberState(t, testMemberModel("member", roleAdmin))
+	plan := newMemberState(t, testMemberModel("member", "管理者"))
+
+	req := fwresource.UpdateRequest{
+		Plan:  tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw},
+		State: state,
+	}
+	resp := fwresource.UpdateResponse{State: state}
+	r.Update(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	var got memberResourceModel
+	if diags := resp.State.Get(ctx, &got); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if got.Role.ValueString() != "管理者" {
+		t.Errorf("unexpected role: %s", got.Role)
+	}
+	if got := api.modifications(); got != nil {
+		t.Errorf("unexpected requests: %v", got)
+	}
+}
+
+func TestBlogMemberResource_ValidateConfig(t *testing.T) {
+	c := client.NewClient("test", "username", "apikey", "owner", "blog.example.com")
+
+	tests := map[string]struct {
+		resource *BlogMemberResource
+		role     string
+		wantErr  bool
+	}{
+		"name":                            {resource: &BlogMemberResource{}, role: "admin"},
+		"label":                           {resource: &BlogMemberResource{}, role: "管理者"},
+		"possible alias before configure": {resource: &BlogMemberResource{}, role: "writer"},
+		"alias":                           {resource: &BlogMemberResource{client: c, roleAliases: roleAliases{"writer": roleContributor}}, role: "writer"},
+		"undefined alias":                 {resource: &BlogMemberResource{client: c}, role: "writer", wantErr: true},
+		"invalid":                         {resource: &BlogMemberResource{client: c, roleAliases: roleAliases{"writer": roleContributor}}, role: "boss", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			req := fwresource.ValidateConfigRequest{Config: testMemberConfig(t, testMemberModel("member", tt.role))}
+			resp := fwresource.ValidateConfigResponse{}
+			tt.resource.ValidateConfig(ctx, req, &resp)
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Errorf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+		})
+	}
+}
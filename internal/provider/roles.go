@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+// メンバーのロールのAPIでの値
+const (
+	roleAdmin       = "admin"
+	roleEditor      = "editor"
+	roleContributor = "contributor"
+)
+
+// blogRole ははてなブログのメンバーのロールとその権限
+type blogRole struct {
+	// Name はAPIでの値
+	Name string
+	// Label ははてなブログの画面での日本語の名前
+	Label string
+	// Rank は権限の強さ。大きいほど多くの操作ができる
+	Rank int
+
+	CanPublish         bool
+	CanEditOthersPosts bool
+	CanManageSettings  bool
+	CanManageMembers   bool
+}
+
+// blogRoles はロールの一覧。権限の強い順に並べる
+// ロールの検証やドキュメントはこの一覧から作るので、ロールを変えるときはここだけを変更すればよい
+var blogRoles = []blogRole{
+	{
+		Name:               roleAdmin,
+		Label:              "管理者",
+		Rank:               3,
+		CanPublish:         true,
+		CanEditOthersPosts: true,
+		CanManageSettings:  true,
+		CanManageMembers:   true,
+	},
+	{
+		Name:               roleEditor,
+		Label:              "編集者",
+		Rank:               2,
+		CanPublish:         true,
+		CanEditOthersPosts: true,
+	},
+	{
+		Name:  roleContributor,
+		Label: "寄稿者",
+		Rank:  1,
+	},
+}
+
+// findRole はAPIでの値からロールを探す
+func findRole(name string) (blogRole, bool) {
+	for _, role := range blogRoles {
+		if role.Name == name {
+			return role, true
+		}
+	}
+	return blogRole{}, false
+}
+
+// roleNames はロールのAPIでの値の一覧を返す
+func roleNames() []string {
+	names := make([]string, 0, len(blogRoles))
+	for _, role := range blogRoles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// roleNamesDescription はスキーマの説明に使うロールの一覧を返す
+// 例: 'admin'（管理者）, 'editor'（編集者）, or 'contributor'（寄稿者）
+func roleNamesDescription() string {
+	names := make([]string, 0, len(blogRoles))
+	for _, role := range blogRoles {
+		names = append(names, fmt.Sprintf("'%s'（%s）", role.Name, role.Label))
+	}
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", or " + names[len(names)-1]
+}
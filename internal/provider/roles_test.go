@@ -0,0 +1,25 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestBlogRoles(t *testing.T) {
+	for i, role := range blogRoles {
+		if i > 0 && blogRoles[i-1].Rank <= role.Rank {
+			t.Errorf("roles must be ordered from the most privileged: %s", role.Name)
+		}
+		if found, ok := findRole(role.Name); !ok || found != role {
+			t.Errorf("failed to find role: %s", role.Name)
+		}
+	}
+
+	if _, ok := findRole("owner"); ok {
+		t.Errorf("owner is not a role of members")
+	}
+
+	want := "'admin'（管理者）, 'editor'（編集者）, or 'contributor'（寄稿者）"
+	if got := roleNamesDescription(); got != want {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+// rosterFingerprintKey はrefresh時のメンバー一覧のフィンガープリントを保存するprivate stateのキー
+const rosterFingerprintKey = "roster_fingerprint"
+
+// privateState はリソースのprivate stateを読み書きするためのインターフェース
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// rosterFingerprint はメンバー一覧のハッシュを返す
+// メンバーの順序には依存しない
+func rosterFingerprint(members []*client.BlogMember) string {
+	lines := make([]string, 0, len(members))
+	for _, member := range members {
+		lines = append(lines, member.Username+"\t"+member.Role)
+	}
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordRosterFingerprint はメンバー一覧のフィンガープリントをprivate stateに記録する
+// members が nil のときは記録を消去する。skip_roster_check のときは比較しないので記録しない
+func (r *BlogMemberResource) recordRosterFingerprint(ctx context.Context, private privateState, members []*client.BlogMember) diag.Diagnostics {
+	if r.skipRosterCheck {
+		return nil
+	}
+
+	value := []byte("null")
+	if members != nil {
+		value, _ = json.Marshal(rosterFingerprint(members))
+	}
+	return private.SetKey(ctx, rosterFingerprintKey, value)
+}
+
+// checkRoster はprivate stateに記録されたフィンガープリントと、
+// このプロバイダが変更を加える前のメンバー一覧を比較し、異なっていればエラーを返す
+// apply時に変更を加える前に呼び出すこと。private が nil のときは比較しない
+func (r *BlogMemberResource) checkRoster(ctx context.Context, private privateState) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if r.skipRosterCheck {
+		return diags
+	}
+
+	// 記録がなくても、変更を加える前のメンバー一覧を確定させるために取得しておく
+	members, err := r.client.InitialMembers(ctx)
+	if err != nil {
+		diags.Append(apiErrorDiagnostic(ctx, r.client, "Failed to list members", err))
+		return diags
+	}
+
+	if private == nil {
+		return diags
+	}
+
+	value, d := private.GetKey(ctx, rosterFingerprintKey)
+	diags.Append(d...)
+	if diags.HasError() || value == nil {
+		return diags
+	}
+	var recorded string
+	if err := json.Unmarshal(value, &recorded); err != nil || recorded == "" {
+		return diags
+	}
+
+	if recorded != rosterFingerprint(members) {
+		diags.AddError(
+			"Roster changed since plan",
+			"The members of the blog have been changed by someone else since they were refreshed during the plan. "+
+				"Run terraform plan again to review the current members before applying. "+
+				"If the plan was made with -refresh=false, the recorded members may be from before a previous apply; refresh the state with terraform apply -refresh-only. "+
+				"Set skip_roster_check in the provider configuration to disable this check.",
+		)
+	}
+
+	return diags
+}
+
+// recordPlannedRoster はplan時のメンバー一覧のフィンガープリントを記録する
+func (r *BlogMemberResource) recordPlannedRoster(ctx context.Context, state tfsdk.State, private privateState) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if r.skipRosterCheck {
+		return diags
+	}
+
+	var stateTimeouts timeouts.Value
+	diags.Append(state.GetAttribute(ctx, path.Root("timeouts"), &stateTimeouts)...)
+	if diags.HasError() {
+		return diags
+	}
+	timeout, d := stateTimeouts.Read(ctx, defaultReadTimeout)
+	diags.Append(d...)
+	if diags.HasError() {
+		return diags
+	}
+	ctx, cancel := withOperationTimeout(ctx, "read", timeout)
+	defer cancel()
+
+	members, err := r.client.ListMembers(ctx)
+	if err != nil {
+		diags.Append(apiErrorDiagnostic(ctx, r.client, "Failed to list members", err))
+		return diags
+	}
+	diags.Append(r.recordRosterFingerprint(ctx, private, members)...)
+	return diags
+}
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hatena/terraform-provider-hatenablog-members/internal/client"
+)
+
+func TestRosterFingerprint(t *testing.T) {
+	a := rosterFingerprint([]*client.BlogMember{
+		{Username: "member1", Role: "admin"},
+		{Username: "member2", Role: "editor"},
+	})
+	b := rosterFingerprint([]*client.BlogMember{
+		{Username: "member2", Role: "editor"},
+		{Username: "member1", Role: "admin"},
+	})
+	if a != b {
+		t.Errorf("fingerprint should not depend on the order of members: %s != %s", a, b)
+	}
+
+	c := rosterFingerprint([]*client.BlogMember{
+		{Username: "member1", Role: "admin"},
+		{Username: "member2", Role: "contributor"},
+	})
+	if a == c {
+		t.Errorf("fingerprint should change when a role changes")
+	}
+
+	d := rosterFingerprint([]*client.BlogMember{
+		{Username: "member1", Role: "admin"},
+	})
+	if a == d {
+		t.Errorf("fingerprint should change when a member is removed")
+	}
+}
+
+// testPrivateState はテスト用の private state
+type testPrivateState map[string][]byte
+
+func (s testPrivateState) GetKey(_ context.Context, key string) ([]byte, diag.Diagnostics) {
+	return s[key], nil
+}
+
+func (s testPrivateState) SetKey(_ context.Context, key string, value []byte) diag.Diagnostics {
+	s[key] = value
+	return nil
+}
+
+func TestBlogMemberResource_checkRoster(t *testing.T) {
+	planned := []*client.BlogMember{{Username: "member", Role: "editor"}}
+	changed := []*client.BlogMember{{Username: "member", Role: "editor"}, {Username: "intruder", Role: "admin"}}
+
+	tests := map[string]struct {
+		recorded        []*client.BlogMember
+		members         []*client.BlogMember
+		skipRosterCheck bool
+		wantErr         bool
+		wantRequests    int
+	}{
+		"unchanged":         {recorded: planned, members: planned, wantRequests: 1},
+		"changed":           {recorded: planned, members: changed, wantErr: true, wantRequests: 1},
+		"not recorded":      {members: changed, wantRequests: 1},
+		"skip roster check": {recorded: planned, members: changed, skipRosterCheck: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			api, c := newFakeMemberAPI(t, tt.members...)
+			r := &BlogMemberResource{client: c, skipRosterCheck: tt.skipRosterCheck}
+
+			private := testPrivateState{}
+			if tt.recorded != nil {
+				private[rosterFingerprintKey], _ = json.Marshal(rosterFingerprint(tt.recorded))
+			}
+
+			diags := r.checkRoster(ctx, private)
+			if diags.HasError() != tt.wantErr {
+				t.Errorf("unexpected diagnostics: %v", diags)
+			}
+			if len(api.requests) != tt.wantRequests {
+				t.Errorf("unexpected requests: %v", api.requests)
+			}
+		})
+	}
+}
+
+func TestBlogMemberResource_recordPlannedRoster(t *testing.T) {
+	members := []*client.BlogMember{{Username: "member", Role: "editor"}}
+
+	t.Run("record", func(t *testing.T) {
+		ctx := context.Background()
+		_, c := newFakeMemberAPI(t, members...)
+		r := &BlogMemberResource{client: c}
+
+		// refresh せずに plan したときの古い記録は、plan 時のメンバー一覧で置き換えられる
+		private := testPrivateState{rosterFingerprintKey: []byte(`"stale"`)}
+		if diags := r.recordPlannedRoster(ctx, newMemberState(t, testMemberModel("member", "editor")), private); diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		want, _ := json.Marshal(rosterFingerprint(members))
+		if got := private[rosterFingerprintKey]; string(got) != string(want) {
+			t.Errorf("unexpected fingerprint: %s, want %s", got, want)
+		}
+
+		// 記録したメンバー一覧から変更されていなければ、apply時の確認は成功する
+		if diags := r.checkRoster(ctx, private); diags.HasError() {
+			t.Errorf("unexpected diagnostics: %v", diags)
+		}
+	})
+
+	t.Run("skip roster check", func(t *testing.T) {
+		ctx := context.Background()
+		api, c := newFakeMemberAPI(t, members...)
+		r := &BlogMemberResource{client: c, skipRosterCheck: true}
+
+		private := testPrivateState{}
+		if diags := r.recordPlannedRoster(ctx, newMemberState(t, testMemberModel("member", "editor")), private); diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		if len(private) != 0 || len(api.requests) != 0 {
+			t.Errorf("nothing should be recorded: %v, requests %v", private, api.requests)
+		}
+	})
+}
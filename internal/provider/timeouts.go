@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// リソースの操作ごとのデフォルトのタイムアウト
+const (
+	defaultCreateTimeout = 5 * time.Minute
+	defaultReadTimeout   = 2 * time.Minute
+	defaultUpdateTimeout = 5 * time.Minute
+	defaultDeleteTimeout = 5 * time.Minute
+)
+
+// operationTimeoutKey は操作のタイムアウトをcontextに保存するためのキー
+type operationTimeoutKey struct{}
+
+// operationTimeout はリソースの操作に設定されたタイムアウト
+type operationTimeout struct {
+	operation string
+	timeout   time.Duration
+}
+
+// withOperationTimeout は操作のタイムアウトを期限として設定したcontextを返す
+// 期限を過ぎたときに apiErrorDiagnostic でどの操作がタイムアウトしたかを示せるように、操作の名前も保存しておく
+func withOperationTimeout(ctx context.Context, operation string, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx = context.WithValue(ctx, operationTimeoutKey{}, operationTimeout{
+		operation: operation,
+		timeout:   timeout,
+	})
+	return context.WithTimeout(ctx, timeout)
+}
+
+// nullTimeouts は timeouts ブロックが指定されていないときの値を返す
+func nullTimeouts() timeouts.Value {
+	return timeouts.Value{
+		Object: types.ObjectNull(map[string]attr.Type{
+			"create": types.StringType,
+			"read":   types.StringType,
+			"update": types.StringType,
+			"delete": types.StringType,
+		}),
+	}
+}
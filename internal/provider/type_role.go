@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// normalizeRole はロールのAPIでの値か日本語の名前をAPIでの値に変換する
+// どちらにも当てはまらないときは false を返す
+func normalizeRole(name string) (string, bool) {
+	for _, role := range blogRoles {
+		if role.Name == name || role.Label == name {
+			return role.Name, true
+		}
+	}
+	return "", false
+}
+
+// roleAliases はプロバイダの role_aliases で設定されたロールの別名から、APIでの値への対応
+// プロバイダの設定ごとに異なるので、プロバイダから各リソースに渡す
+type roleAliases map[string]string
+
+// normalize はロールのAPIでの値、日本語の名前、別名のいずれかをAPIでの値に変換する
+// どれにも当てはまらないときは false を返す
+func (a roleAliases) normalize(name string) (string, bool) {
+	if role, ok := normalizeRole(name); ok {
+		return role, true
+	}
+	role, ok := a[name]
+	return role, ok
+}
+
+// canonical はロールのAPIでの値を返す
+// 既知のロールでなければ値をそのまま返す
+func (a roleAliases) canonical(v roleValue) string {
+	if role, ok := a.normalize(v.ValueString()); ok {
+		return role
+	}
+	return v.ValueString()
+}
+
+// stateValue はAPIでのロール role を state に保存する値を返す
+// 以前の値 prior が同じロールを表す日本語の名前や別名であれば、設定との差分にならないように prior を返す
+func (a roleAliases) stateValue(prior roleValue, role string) roleValue {
+	if !prior.IsNull() && !prior.IsUnknown() && a.canonical(prior) == role {
+		return prior
+	}
+	return newRoleValue(role)
+}
+
+// roleType はロールを表す文字列の型
+// 日本語の名前や別名と、APIでの値を同じロールとして扱う
+type roleType struct {
+	basetypes.StringType
+}
+
+// ensure that roleType satisfies interfaces
+var _ basetypes.StringTypable = roleType{}
+
+func (t roleType) Equal(o attr.Type) bool {
+	other, ok := o.(roleType)
+	if !ok {
+		return false
+	}
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t roleType) String() string {
+	return "roleType"
+}
+
+func (t roleType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return roleValue{StringValue: in}, nil
+}
+
+func (t roleType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+	return roleValue{StringValue: stringValue}, nil
+}
+
+func (t roleType) ValueType(ctx context.Context) attr.Value {
+	return roleValue{}
+}
+
+// roleValue はロールを表す文字列の値
+type roleValue struct {
+	basetypes.StringValue
+}
+
+// ensure that roleValue satisfies interfaces
+var _ basetypes.StringValuableWithSemanticEquals = roleValue{}
+
+func newRoleValue(role string) roleValue {
+	return roleValue{StringValue: basetypes.NewStringValue(role)}
+}
+
+func newRoleNull() roleValue {
+	return roleValue{StringValue: basetypes.NewStringNull()}
+}
+
+func (v roleValue) Equal(o attr.Value) bool {
+	other, ok := o.(roleValue)
+	if !ok {
+		return false
+	}
+	return v.StringValue.Equal(other.StringValue)
+}
+
+func (v roleValue) Type(ctx context.Context) attr.Type {
+	return roleType{}
+}
+
+// StringSemanticEquals は同じロールを表していれば等しいとみなす
+// これにより、設定に日本語の名前が書かれていてAPIからはAPIでの値が返ってきても差分にならない
+// 別名はプロバイダの設定によるので、リソースが roleAliases.stateValue で以前の値を保つ
+func (v roleValue) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(roleValue)
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			fmt.Sprintf("Expected value type %T but got value type %T. Please report this to the provider developers.", v, newValuable),
+		)
+		return false, diags
+	}
+
+	prior, ok := normalizeRole(v.ValueString())
+	if !ok {
+		return false, diags
+	}
+	next, ok := normalizeRole(newValue.ValueString())
+	if !ok {
+		return false, diags
+	}
+	return prior == next, diags
+}
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRoleAliases_normalize(t *testing.T) {
+	aliases := roleAliases{"writer": roleContributor}
+
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{name: "admin", want: roleAdmin, wantOK: true},
+		{name: "管理者", want: roleAdmin, wantOK: true},
+		{name: "編集者", want: roleEditor, wantOK: true},
+		{name: "寄稿者", want: roleContributor, wantOK: true},
+		{name: "writer", want: roleContributor, wantOK: true},
+		{name: "owner", wantOK: false},
+		{name: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := aliases.normalize(tt.name)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("unexpected result: %q, %v", got, ok)
+			}
+		})
+	}
+
+	// 別名を設定していないプロバイダでは別名は使えない
+	if _, ok := roleAliases(nil).normalize("writer"); ok {
+		t.Error("alias should not be accepted without role_aliases")
+	}
+}
+
+func TestRoleValue_StringSemanticEquals(t *testing.T) {
+	tests := []struct {
+		prior, next string
+		want        bool
+	}{
+		{prior: "admin", next: "admin", want: true},
+		{prior: "管理者", next: "admin", want: true},
+		{prior: "admin", next: "管理者", want: true},
+		{prior: "管理者", next: "editor", want: false},
+		{prior: "unknown", next: "unknown", want: false},
+		// 別名はプロバイダの設定によるので、型では同じロールとみなさない
+		{prior: "writer", next: "contributor", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prior+"="+tt.next, func(t *testing.T) {
+			got, diags := newRoleValue(tt.prior).StringSemanticEquals(context.Background(), newRoleValue(tt.next))
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected result: %v", got)
+			}
+		})
+	}
+}
+
+func TestRoleAliases_canonical(t *testing.T) {
+	aliases := roleAliases{"writer": roleContributor}
+
+	if got := aliases.canonical(newRoleValue("編集者")); got != roleEditor {
+		t.Errorf("unexpected canonical role: %s", got)
+	}
+	if got := aliases.canonical(newRoleValue("writer")); got != roleContributor {
+		t.Errorf("unexpected canonical role: %s", got)
+	}
+	if got := aliases.canonical(newRoleValue("unknown")); got != "unknown" {
+		t.Errorf("unexpected canonical role: %s", got)
+	}
+}
+
+func TestRoleAliases_stateValue(t *testing.T) {
+	aliases := roleAliases{"writer": roleContributor}
+
+	tests := []struct {
+		name  string
+		prior roleValue
+		role  string
+		want  roleValue
+	}{
+		{name: "same name", prior: newRoleValue("admin"), role: roleAdmin, want: newRoleValue("admin")},
+		{name: "label", prior: newRoleValue("管理者"), role: roleAdmin, want: newRoleValue("管理者")},
+		{name: "alias", prior: newRoleValue("writer"), role: roleContributor, want: newRoleValue("writer")},
+		{name: "changed", prior: newRoleValue("管理者"), role: roleEditor, want: newRoleValue(roleEditor)},
+		{name: "imported", prior: newRoleNull(), role: roleAdmin, want: newRoleValue(roleAdmin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aliases.stateValue(tt.prior, tt.role); !got.Equal(tt.want) {
+				t.Errorf("unexpected value: %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestDurationValidator(t *testing.T) {
+	tests := map[string]struct {
+		value     types.String
+		wantError bool
+	}{
+		"null":     {value: types.StringNull()},
+		"unknown":  {value: types.StringUnknown()},
+		"seconds":  {value: types.StringValue("30s")},
+		"minutes":  {value: types.StringValue("1m30s")},
+		"zero":     {value: types.StringValue("0s"), wantError: true},
+		"negative": {value: types.StringValue("-1s"), wantError: true},
+		"unitless": {value: types.StringValue("30"), wantError: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := validator.StringRequest{
+				Path:        path.Root("request_timeout"),
+				ConfigValue: tt.value,
+			}
+			resp := &validator.StringResponse{}
+			durationValidator{}.ValidateString(context.Background(), req, resp)
+
+			if resp.Diagnostics.HasError() != tt.wantError {
+				t.Errorf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRFC3339Validator(t *testing.T) {
+	tests := map[string]struct {
+		value     types.String
+		wantError bool
+	}{
+		"null":     {value: types.StringNull()},
+		"unknown":  {value: types.StringUnknown()},
+		"utc":      {value: types.StringValue("2024-01-02T03:04:05Z")},
+		"offset":   {value: types.StringValue("2024-01-02T03:04:05+09:00")},
+		"date":     {value: types.StringValue("2024-01-02"), wantError: true},
+		"freeform": {value: types.StringValue("tomorrow"), wantError: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := validator.StringRequest{
+				Path:        path.Root("expires_at"),
+				ConfigValue: tt.value,
+			}
+			resp := &validator.StringResponse{}
+			rfc3339Validator{}.ValidateString(context.Background(), req, resp)
+
+			if resp.Diagnostics.HasError() != tt.wantError {
+				t.Errorf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+		})
+	}
+}